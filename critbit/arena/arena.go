@@ -0,0 +1,164 @@
+// Package arena manages the keys stored by critbit containers. By default a
+// container keeps the caller's key slices, so modifying a slice after an
+// insertion silently corrupts the tree. An Arena can copy keys into large
+// append-only blocks instead or, in the debug mode, detect such mutations.
+package arena
+
+import "bytes"
+import "fmt"
+
+
+type Mode byte
+
+const (
+	// Borrow stores keys as passed by the caller
+	Borrow Mode = iota
+	// Copy stores copies of keys in the arena
+	Copy
+	// Debug stores keys as passed but remembers their content to detect
+	// mutations after insertion (see Arena.Check)
+	Debug
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Borrow:
+		return "Borrow"
+	case Copy:
+		return "Copy"
+	case Debug:
+		return "Debug"
+	}
+	return fmt.Sprintf("Mode(%d)", byte(m))
+}
+
+// blockSize is the size of arena blocks (longer keys get blocks of their own)
+const blockSize = 64 << 10
+
+type Arena struct {
+	mode  Mode
+	// block is the current block, keys are appended to it
+	block []byte
+	// size is the number of bytes of all blocks
+	size  int
+	// snaps holds the original content of borrowed keys (in the debug mode)
+	snaps map[snapKey]*snapshot
+}
+
+// snapKey identifies a borrowed key slice
+type snapKey struct {
+	ptr *byte
+	len int
+}
+
+// snapshot is the content of a key slice when it was first stored. A slice
+// reused for several leaves keeps it, so a mutation in between is detected.
+type snapshot struct {
+	data   []byte
+	// leaves is the number of leaves holding the slice
+	leaves int
+}
+
+func New(mode Mode) *Arena {
+	a := &Arena{mode: mode}
+	if mode == Debug {
+		a.snaps = make(map[snapKey]*snapshot)
+	}
+	return a
+}
+
+func (a *Arena) Mode() Mode {
+	return a.mode
+}
+
+// Size returns the number of bytes allocated for copies of keys.
+func (a *Arena) Size() int {
+	return a.size
+}
+
+// Store returns a key to be stored in a container according to the mode.
+func (a *Arena) Store(key []byte) []byte {
+	switch a.mode {
+	case Copy:
+		return a.Copy(key)
+	case Debug:
+		a.track(key, nil)
+	}
+	return key
+}
+
+// Copy returns a copy of a key in the arena regardless of the mode.
+// The copy has no spare capacity, so appending to it never clobbers others.
+func (a *Arena) Copy(key []byte) []byte {
+	if len(key) > cap(a.block) - len(a.block) {
+		a.block = make([]byte, 0, max(blockSize, len(key)))
+		a.size += cap(a.block)
+	}
+	start := len(a.block)
+	a.block = append(a.block, key...)
+	return a.block[start:len(a.block):len(a.block)]
+}
+
+// Release tells the arena a key is no longer stored in the container.
+func (a *Arena) Release(key []byte) {
+	if a.mode == Debug {
+		a.untrack(key)
+	}
+}
+
+// track adds a leaf holding a key slice with the content of snap (the
+// current content if nil). A slice tracked already keeps its snapshot.
+func (a *Arena) track(key, snap []byte) {
+	if len(key) == 0 {
+		return
+	}
+	id := snapKey{&key[0], len(key)}
+	if s, ok := a.snaps[id]; ok {
+		s.leaves++
+		return
+	}
+	if snap == nil {
+		snap = bytes.Clone(key)
+	}
+	a.snaps[id] = &snapshot{data: snap, leaves: 1}
+}
+
+// untrack removes a leaf holding a key slice and returns the snapshot of it
+func (a *Arena) untrack(key []byte) (snap []byte, ok bool) {
+	if len(key) == 0 {
+		return nil, false
+	}
+	id := snapKey{&key[0], len(key)}
+	s, ok := a.snaps[id]
+	if ! ok {
+		return nil, false
+	}
+	if s.leaves--; s.leaves == 0 {
+		delete(a.snaps, id)
+	}
+	return s.data, true
+}
+
+// Check returns an error if a stored key has been modified since Store.
+// It only detects anything in the debug mode.
+func (a *Arena) Check(key []byte) error {
+	if a.mode != Debug || len(key) == 0 {
+		return nil
+	}
+	if snap, ok := a.snaps[snapKey{&key[0], len(key)}]; ok && ! bytes.Equal(snap.data, key) {
+		return fmt.Errorf("arena: key %q was modified after insertion (was %q)", key, snap.data)
+	}
+	return nil
+}
+
+// Compact drops all blocks and copies the live keys into new ones. A walk
+// func must replace every stored key with the result of move. It does
+// nothing in the Borrow and Debug modes: the container keeps the caller's
+// keys there and copying them would change the mode behind its back.
+func (a *Arena) Compact(walk func(move func([]byte) []byte)) {
+	if a.mode != Copy {
+		return
+	}
+	a.block, a.size = nil, 0
+	walk(a.Copy)
+}
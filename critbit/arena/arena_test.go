@@ -0,0 +1,129 @@
+package arena
+
+import "testing"
+import "bytes"
+
+func Test_Modes(t *testing.T) {
+	key := []byte("abc")
+
+	if k := New(Borrow).Store(key); &k[0] != &key[0] {
+		t.Error("Borrow must store the key as is")
+	}
+	a := New(Copy)
+	k := a.Store(key)
+	if &k[0] == &key[0] || ! bytes.Equal(k, key) || cap(k) != len(k) {
+		t.Errorf("Copy must store a copy without spare capacity, got %q (cap %d)", k, cap(k))
+	}
+	if a.Size() != blockSize {
+		t.Errorf("wrong arena size %d", a.Size())
+	}
+	if long := a.Copy(make([]byte, blockSize + 1)); len(long) != blockSize + 1 || a.Size() != 2*blockSize + 1 {
+		t.Errorf("wrong arena size %d after a long key", a.Size())
+	}
+
+	d := New(Debug)
+	if k := d.Store(key); &k[0] != &key[0] {
+		t.Error("Debug must store the key as is")
+	}
+	if err := d.Check(key); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	key[1] = 'X'
+	if err := d.Check(key); err == nil {
+		t.Error("Check must detect a modified key")
+	}
+	d.Release(key)
+	if err := d.Check(key); err != nil {
+		t.Errorf("a released key must not be checked: %v", err)
+	}
+}
+
+func Test_Compact(t *testing.T) {
+	a := New(Copy)
+	keys := make([][]byte, 100)
+	for i := range keys {
+		keys[i] = a.Store(bytes.Repeat([]byte{byte(i)}, 1000))
+	}
+	size := a.Size()
+	// keep every tenth key
+	a.Compact(func(move func([]byte) []byte) {
+		for i := 0; i < len(keys); i += 10 {
+			keys[i] = move(keys[i])
+		}
+	})
+	if a.Size() >= size {
+		t.Errorf("Compact must shrink the arena: %d -> %d", size, a.Size())
+	}
+	for i := 0; i < len(keys); i += 10 {
+		if ! bytes.Equal(keys[i], bytes.Repeat([]byte{byte(i)}, 1000)) {
+			t.Errorf("key %d was corrupted by Compact", i)
+		}
+	}
+}
+
+func Test_CompactBorrowed(t *testing.T) {
+	for _, mode := range []Mode{Borrow, Debug} {
+		a := New(mode)
+		key := []byte("abc")
+		k := a.Store(key)
+		a.Compact(func(move func([]byte) []byte) {
+			t.Errorf("%v: Compact must not move keys", mode)
+			k = move(k)
+		})
+		if &k[0] != &key[0] || a.Size() != 0 {
+			t.Errorf("%v: Compact must keep the borrowed key", mode)
+		}
+		key[0] = 'X'
+		if err := a.Check(k); mode == Debug && err == nil {
+			t.Errorf("%v: Check must still detect a modified key", mode)
+		}
+	}
+}
+
+func Test_Keys(t *testing.T) {
+	var k Keys
+	key := []byte("abc")
+	if s := k.Store(key, false); &s[0] != &key[0] || k.Mode() != Borrow || k.Size() != 0 {
+		t.Error("the zero Keys must store keys as passed")
+	}
+	if s := k.Store(key, true); &s[0] == &key[0] || k.Mode() != Borrow || k.Size() != blockSize {
+		t.Error("a per-call copy must go to a Borrow arena")
+	}
+
+	k.SetMode(Debug)
+	stored := [][]byte{k.Store(key, false), k.Store([]byte("xyz"), false)}
+	walk := func(visit func([]byte) bool) {
+		for _, s := range stored {
+			if ! visit(s) {
+				return
+			}
+		}
+	}
+	if err := k.Check(walk); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	key[0] = 'X'
+	if err := k.Check(walk); err == nil {
+		t.Error("Check must detect a modified key")
+	}
+}
+
+func Test_DebugReusedBuffer(t *testing.T) {
+	a := New(Debug)
+	buf := []byte("a")
+	first := a.Store(buf)
+	copy(buf, "z")
+	second := a.Store(buf)
+	if a.Check(first) == nil || a.Check(second) == nil {
+		t.Error("Check must detect a buffer modified between two stores")
+	}
+	// the snapshot lives as long as a leaf holds the buffer
+	a.Release(first)
+	if a.Check(second) == nil {
+		t.Error("Release of one leaf must keep the snapshot of the other")
+	}
+	a.Release(second)
+	if err := a.Check(second); err != nil {
+		t.Errorf("a released key must not be checked: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package arena
+
+
+// Keys handles the keys of a container: it stores and releases them through
+// an arena created on demand. The zero value stores keys as passed. Walking
+// the leaves is up to the container, see Compact and Check.
+type Keys struct {
+	arena *Arena
+}
+
+// SetMode makes subsequent insertions store keys according to a mode.
+func (k *Keys) SetMode(mode Mode) {
+	k.arena = New(mode)
+}
+
+// Mode returns the mode of the keys (Borrow if no mode was set).
+func (k *Keys) Mode() Mode {
+	if k.arena == nil {
+		return Borrow
+	}
+	return k.arena.mode
+}
+
+// Size returns the number of bytes allocated for copies of keys.
+func (k *Keys) Size() int {
+	if k.arena == nil {
+		return 0
+	}
+	return k.arena.size
+}
+
+// Store returns a key to be stored in a new leaf: a copy if copy is set,
+// otherwise according to the mode.
+func (k *Keys) Store(key []byte, copy bool) []byte {
+	switch {
+	case copy:
+		if k.arena == nil {
+			k.arena = New(Borrow)
+		}
+		return k.arena.Copy(key)
+	case k.arena != nil:
+		return k.arena.Store(key)
+	}
+	return key
+}
+
+// Release tells the arena a key is no longer stored.
+func (k *Keys) Release(key []byte) {
+	if k.arena != nil {
+		k.arena.Release(key)
+	}
+}
+
+// Compact copies the keys into fresh blocks in the Copy mode (see
+// Arena.Compact). A walk func must replace every stored key with the result
+// of move.
+func (k *Keys) Compact(walk func(move func([]byte) []byte)) {
+	if k.arena != nil {
+		k.arena.Compact(walk)
+	}
+}
+
+// Check returns the first error of Arena.Check for the keys a walk func
+// passes to visit. The walk must stop once visit returns false.
+func (k *Keys) Check(walk func(visit func([]byte) bool)) (err error) {
+	if k.arena == nil {
+		return nil
+	}
+	walk(func(key []byte) bool {
+		err = k.arena.Check(key)
+		return err == nil
+	})
+	return
+}
+
+// Fork returns Keys for another container in the same mode. They share no
+// arena with these ones, so compacting either leaves the other intact.
+func (k *Keys) Fork() Keys {
+	if k.arena == nil {
+		return Keys{}
+	}
+	return Keys{arena: New(k.arena.mode)}
+}
+
+// Transfer hands keys over to other Keys when leaves are relinked into
+// another container. A walk func must replace every moved key with the
+// result of move: a copy if the keys go to the Copy mode from another one,
+// otherwise the key itself with its debug snapshot moved along. Nothing is
+// walked when neither side needs it.
+func (k *Keys) Transfer(to *Keys, walk func(move func([]byte) []byte)) {
+	from, dst := k.Mode(), to.Mode()
+	if from != Debug && dst != Debug && (dst != Copy || from == Copy) {
+		return
+	}
+	walk(func(key []byte) []byte {
+		var snap []byte
+		if from == Debug {
+			snap, _ = k.arena.untrack(key)
+		}
+		switch {
+		case dst == Copy && from != Copy:
+			return to.arena.Copy(key)
+		case dst == Debug:
+			to.arena.track(key, snap)
+		}
+		return key
+	})
+}
@@ -0,0 +1,98 @@
+package counter
+
+import "fmt"
+import "iter"
+
+
+// BuildSorted builds a counter of keys given in increasing order in linear
+// time. It fails on out-of-order and duplicate keys.
+func BuildSorted(ckeys CountedKeySlice) (*Counter, error) {
+	var b builder
+	for _, ckey := range ckeys {
+		if err := b.add(ckey.Key, ckey.Count); err != nil {
+			return nil, err
+		}
+	}
+	return b.finish(&Counter{}), nil
+}
+
+// BuildSortedSeq is like BuildSorted but takes keys and counts from an iterator.
+func BuildSortedSeq(seq iter.Seq2[[]byte, int]) (*Counter, error) {
+	var b builder
+	for key, count := range seq {
+		if err := b.add(key, count); err != nil {
+			return nil, err
+		}
+	}
+	return b.finish(&Counter{}), nil
+}
+
+
+// builder assembles a tree bottom-up from keys coming in increasing order.
+// It keeps the right spine of the tree built so far, so that every key is
+// linked in amortized O(1) time. Subtree sizes are computed by finish().
+type builder struct {
+	root  Ref
+	// spine holds the Refs on the right edge of the tree (from the root down)
+	spine []*Ref
+	size  int
+}
+
+// add appends a key which must be greater than all keys added before.
+func (b *builder) add(key []byte, count int) error {
+	if len(key) == 0 {
+		return fmt.Errorf("counter: empty key at position %d", b.size)
+	}
+	if b.size == 0 {
+		b.root.CountedKey = CountedKey{key, count}
+		b.spine = append(b.spine[:0], &b.root)
+		b.size++
+		return nil
+	}
+	last := b.spine[len(b.spine)-1]
+	off, bit, ldir, differ := critbit(key, last.Key)
+	if ! differ {
+		return fmt.Errorf("counter: duplicate key %q at position %d", key, b.size)
+	}
+	if ldir == 1 {
+		return fmt.Errorf("counter: key %q at position %d is less than the previous key %q", key, b.size, last.Key)
+	}
+	// ascend the spine while the nodes are below the new crit bit
+	i := len(b.spine) - 1
+	for i > 0 {
+		n := b.spine[i-1].node
+		if n.off < off || n.off == off && n.bit > bit {
+			break
+		}
+		i--
+	}
+	// insert new node (the new key always goes right)
+	wp := b.spine[i]
+	nn := &Node{off:off, bit:bit}
+	nn.child[0] = *wp
+	nn.child[1].CountedKey = CountedKey{key, count}
+	*wp = Ref{node: nn}
+
+	b.spine = append(b.spine[:i+1], &nn.child[1])
+	b.size++
+	return nil
+}
+
+// finish computes subtree sizes, moves the tree into a Counter and returns it.
+func (b *builder) finish(t *Counter) *Counter {
+	sumSizes(&b.root)
+	t.root = b.root
+	t.size = b.size
+	*b = builder{}
+	return t
+}
+
+// sumSizes sets subtree sizes of all nodes under the Ref and returns the count
+func sumSizes(ref *Ref) int {
+	if ref.node == nil {
+		return 1
+	}
+	n := ref.node
+	n.size = sumSizes(&n.child[0]) + sumSizes(&n.child[1])
+	return n.size
+}
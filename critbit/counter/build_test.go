@@ -0,0 +1,36 @@
+package counter
+
+import "testing"
+import "strings"
+
+func Test_BuildSorted(t *testing.T) {
+	ckeys := CountedKeySlice{
+		{[]byte("aa"), 1}, {[]byte("aaa"), 2}, {[]byte("ab"), 3}, {[]byte("b"), 0},
+		{[]byte("ba"), -1}, {[]byte("bba"), 5}, {[]byte("c"), 6},
+	}
+	tr, err := BuildSorted(ckeys)
+	if err != nil {
+		t.Fatalf("BuildSorted failed: %v", err)
+	}
+	if tr.Len() != len(ckeys) {
+		t.Errorf("wrong length %d", tr.Len())
+	}
+	for _, ckey := range ckeys {
+		if c := tr.Get(ckey.Key); c != ckey.Count {
+			t.Errorf("wrong count of %q: expected %d, got %d", ckey.Key, ckey.Count, c)
+		}
+	}
+	seq, err := BuildSortedSeq(tr.All())
+	if err != nil || ! testKeysEq(seq.KeysReverse(), tr.KeysReverse()) {
+		t.Errorf("BuildSortedSeq -> %q, %v", seq.Keys(), err)
+	}
+
+	bad := CountedKeySlice{{[]byte("a"), 1}, {[]byte("c"), 1}, {[]byte("b"), 1}}
+	if _, err := BuildSorted(bad); err == nil || ! strings.Contains(err.Error(), "less than the previous key") {
+		t.Errorf("expected an out-of-order error, got %v", err)
+	}
+	bad = CountedKeySlice{{[]byte("a"), 1}, {[]byte("a"), 2}}
+	if _, err := BuildSorted(bad); err == nil || ! strings.Contains(err.Error(), "duplicate key") {
+		t.Errorf("expected a duplicate key error, got %v", err)
+	}
+}
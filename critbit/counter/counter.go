@@ -1,8 +1,8 @@
 package counter
 
-import "fmt"
 import "sort"
 import "bytes"
+import "analyzers/lib/critbit/arena"
 
 
 
@@ -18,8 +18,18 @@ type Ref struct {
 	node *Node
 }
 
+// count returns the number of leaves under the Ref
+func (ref *Ref) count() int {
+	if ref.node != nil {
+		return ref.node.size
+	}
+	return 1
+}
+
 type Node struct {
 	child [2]Ref
+	// size is the number of leaves in the subtree
+	size  int
 	// off is the offset of the differing byte
 	off   int
 	// bit contains the single crit bit in the differing byte
@@ -30,6 +40,8 @@ type Node struct {
 type Counter struct {
 	size int
 	root Ref
+	// arena stores the keys (the zero value stores them as passed)
+	arena arena.Keys
 }
 
 // dir calculates the direction for the given key
@@ -41,7 +53,7 @@ func (n *Node) dir(key []byte) byte {
 }
 
 func InitCounter(counter *Counter, counted_keys ...CountedKey) *Counter {
-	*counter = Counter{}
+	*counter = Counter{arena: counter.arena}
 	for _, ckey := range counted_keys {
 		counter.IncBy(ckey.Key, ckey.Count)
 	}
@@ -90,9 +102,14 @@ func (t *Counter) Get(key []byte) (count int) {
 
 // Replace applies a func to a previous count of a key and replaces the value with return value
 func (t *Counter) Replace(key []byte, replace func(int) int) int {
+	return t.replace(key, replace, false)
+}
+
+// replace is Replace storing a copy of a new key if necessary
+func (t *Counter) replace(key []byte, replace func(int) int, copy bool) int {
 	// test for empty tree
 	if t.Empty() {
-		t.root.Key   = key
+		t.root.Key   = t.arena.Store(key, copy)
 		t.root.Count = replace(0)
 		t.size++
 		return 0
@@ -139,7 +156,7 @@ ByteFound:
 	}
 	// insert new node
 	nn := Node{off:off, bit:bit}
-	nn.child[1-ndir].CountedKey = CountedKey{key, replace(0)}
+	nn.child[1-ndir].CountedKey = CountedKey{t.arena.Store(key, copy), replace(0)}
 
 	// walk for best insertion node
 	wp := &t.root
@@ -148,10 +165,12 @@ ByteFound:
 		if n.off > off || n.off == off && n.bit < bit {
 			break
 		}
+		n.size++
 		// try next node
 		wp = &n.child[n.dir(key)]
 	}
 	nn.child[ndir] = *wp
+	nn.size = wp.count() + 1
 	wp.node = &nn
 	wp.Key  = nil
 	t.size++
@@ -208,15 +227,40 @@ func (t *Counter) Del(key []byte) (count int) {
 	count = p.Count
 	// delete from the tree
 	t.size--
+	t.arena.Release(p.Key)
 	if wp == nil {
 		count  = t.root.Count
 		t.root = Ref{}
 		return
 	}
+	t.resize(key, wp, -1)
 	*wp = wp.node.child[1-dir]
 	return
 }
 
+// DeletePrefix removes all keys with a given prefix and returns their number.
+// The whole subtree is unlinked at once and the number comes from its size,
+// so it takes O(depth) time (plus O(k) for k removed keys in the
+// arena.Debug mode).
+func (t *Counter) DeletePrefix(prefix []byte) int {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return 0
+	}
+	n := top.count()
+	t.size -= n
+	if t.arena.Mode() == arena.Debug {
+		// only the debug mode keeps track of the stored keys
+		t.iterate(*top, 0, func(ckey CountedKey) bool {
+			t.arena.Release(ckey.Key)
+			return true
+		})
+	}
+	t.resize(prefix, parent, -n)
+	t.unlink(top, parent)
+	return n
+}
+
 // Merge merges another Counter into this one. Counters of common keys are added up.
 // Returns itself.
 func (t *Counter) Merge(other *Counter, prefix []byte) *Counter {
@@ -230,49 +274,198 @@ func (t *Counter) Merge(other *Counter, prefix []byte) *Counter {
 	return t
 }
 
-// Iter calls a handler for all keys with a given prefix.
-// It returns whether all prefixed keys were iterated.
-// The handler can continue the process by returning true or abort with false.
-func (t *Counter) Iter(prefix []byte, handler func(CountedKey) bool) bool {
+// Min returns the smallest counted key with a given prefix.
+func (t *Counter) Min(prefix []byte) (ckey CountedKey, ok bool) {
+	if leaf, _ := t.edge(prefix, 0); leaf != nil {
+		return leaf.CountedKey, true
+	}
+	return
+}
+
+// Max returns the greatest counted key with a given prefix.
+func (t *Counter) Max(prefix []byte) (ckey CountedKey, ok bool) {
+	if leaf, _ := t.edge(prefix, 1); leaf != nil {
+		return leaf.CountedKey, true
+	}
+	return
+}
+
+// PopMin removes the smallest counted key with a given prefix and returns it.
+func (t *Counter) PopMin(prefix []byte) (ckey CountedKey, ok bool) {
+	return t.pop(prefix, 0)
+}
+
+// PopMax removes the greatest counted key with a given prefix and returns it.
+func (t *Counter) PopMax(prefix []byte) (ckey CountedKey, ok bool) {
+	return t.pop(prefix, 1)
+}
+
+// pop removes the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix.
+func (t *Counter) pop(prefix []byte, dir byte) (ckey CountedKey, ok bool) {
+	leaf, parent := t.edge(prefix, dir)
+	if leaf == nil {
+		return
+	}
+	ckey, ok = leaf.CountedKey, true
+	// delete from the tree
+	t.size--
+	t.arena.Release(ckey.Key)
+	t.resize(ckey.Key, parent, -1)
+	t.unlink(leaf, parent)
+	return
+}
+
+// edge finds the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix
+// along with the Ref of its parent node.
+func (t *Counter) edge(prefix []byte, dir byte) (leaf, parent *Ref) {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return nil, nil
+	}
+	leaf = top
+	for leaf.node != nil {
+		parent = leaf
+		leaf = &leaf.node.child[dir]
+	}
+	return
+}
+
+// resize adds delta to subtree sizes of the nodes on the path of key
+// above the stop Ref
+func (t *Counter) resize(key []byte, stop *Ref, delta int) {
+	if stop == nil {
+		return
+	}
+	for p := &t.root; p != stop; p = &p.node.child[p.node.dir(key)] {
+		p.node.size += delta
+	}
+}
+
+// unlink removes the subtree at ref from its parent node (nil for the root).
+func (t *Counter) unlink(ref, parent *Ref) {
+	if parent == nil {
+		t.root = Ref{}
+		return
+	}
+	if &parent.node.child[0] == ref {
+		*parent = parent.node.child[1]
+	} else {
+		*parent = parent.node.child[0]
+	}
+}
+
+// findTop returns the topmost Ref holding all keys with a given prefix
+// (nil if there are no such keys) along with the Ref of its parent node.
+func (t *Counter) findTop(prefix []byte) (top, parent *Ref) {
 	// test empty tree
 	if t.Empty() {
-		return true
-	}
-	// shortcut for empty prefix
-	if len(prefix) == 0 {
-		return t.iterate(t.root, handler)
+		return
 	}
 	// walk for best member
-	p, top := t.root, t.root
+	p := &t.root
+	top = p
 	for p.node != nil {
 		newtop := p.node.off < len(prefix)
+		wp := p
 		// try next node
-		p = p.node.child[p.node.dir(prefix)]
+		p = &p.node.child[p.node.dir(prefix)]
 		if newtop {
-			top = p
+			top, parent = p, wp
 		}
 	}
 	if len(p.Key) < len(prefix) {
-		return true
+		return nil, nil
 	}
 	for i := 0; i < len(prefix); i++ {
 		if p.Key[i] != prefix[i] {
-			return true
+			return nil, nil
 		}
 	}
-	return t.iterate(top, handler)
+	return
+}
+
+// Iter calls a handler for all keys with a given prefix.
+// It returns whether all prefixed keys were iterated.
+// The handler can continue the process by returning true or abort with false.
+func (t *Counter) Iter(prefix []byte, handler func(CountedKey) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 0, handler)
+	}
+	return true
+}
+
+// IterReverse is like Iter but calls the handler in a reverse key order.
+func (t *Counter) IterReverse(prefix []byte, handler func(CountedKey) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 1, handler)
+	}
+	return true
 }
 
 // iterate calls the key handler or traverses both node children unless aborted.
-func (t *Counter) iterate(p Ref, h func(CountedKey) bool) bool {
+// The children are visited in key order (rev=0) or in reverse order (rev=1).
+func (t *Counter) iterate(p Ref, rev byte, h func(CountedKey) bool) bool {
 	if p.node != nil {
-		return t.iterate(p.node.child[0], h) && t.iterate(p.node.child[1], h)
+		return t.iterate(p.node.child[rev], rev, h) && t.iterate(p.node.child[1-rev], rev, h)
 	}
 	return h(p.CountedKey)
 }
 
+// iterateFrom calls the key handler for all keys greater-or-equal to lo in key order.
+// It descends once along lo collecting the right siblings to visit afterwards.
+func (t *Counter) iterateFrom(lo []byte, h func(CountedKey) bool) bool {
+	// test empty tree
+	if t.Empty() {
+		return true
+	}
+	// walk for best member
+	p := t.root
+	for p.node != nil {
+		// try next node
+		p = p.node.child[p.node.dir(lo)]
+	}
+	off, bit, pdir, differ := critbit(lo, p.Key)
+
+	// walk down to the insertion point of lo remembering greater siblings
+	var rest []Ref
+	wp := t.root
+	for wp.node != nil {
+		n := wp.node
+		if differ && (n.off > off || n.off == off && n.bit < bit) {
+			break
+		}
+		dir := n.dir(lo)
+		if dir == 0 {
+			rest = append(rest, n.child[1])
+		}
+		wp = n.child[dir]
+	}
+	// the subtree at the insertion point is either all greater or all lesser
+	if ! differ || pdir == 1 {
+		if ! t.iterate(wp, 0, h) {
+			return false
+		}
+	}
+	for i := len(rest) - 1; i >= 0; i-- {
+		if ! t.iterate(rest[i], 0, h) {
+			return false
+		}
+	}
+	return true
+}
+
 // Keys returns all keys, as a slice of []byte, in a sorted order.
 func (t *Counter) Keys() [][]byte {
+	return t.keys(0)
+}
+
+// KeysReverse returns all keys, as a slice of []byte, in a reverse sorted order.
+func (t *Counter) KeysReverse() [][]byte {
+	return t.keys(1)
+}
+
+// keys collects all keys in key order (rev=0) or in reverse order (rev=1).
+func (t *Counter) keys(rev byte) [][]byte {
 	keys := make([][]byte, 0, t.size)
 
 	// empty tree?
@@ -298,7 +491,7 @@ func (t *Counter) Keys() [][]byte {
 			keys = append(keys, p.Key)
 		} else {
 			// unshift the children and continue
-			to_visit = append(to_visit, &p.node.child[1], &p.node.child[0])
+			to_visit = append(to_visit, &p.node.child[1-rev], &p.node.child[rev])
 		}
 	}
 	return keys
@@ -340,16 +533,40 @@ func (t *Counter) CountedKeys() CountedKeySlice {
 	return pairs
 }
 
-func (t *Counter) debug_dump(node *Node, indent string) {
-	fmt.Println(indent, "NODE", node)
-	println(indent, "Left:  off=", node.off, "bit=", node.bit, "key=", string(node.child[0].Key))
-	if node.child[0].node != nil {
-		t.debug_dump(node.child[0].node, indent + "  ")
+// critbit finds the first bit the key differs from another key in.
+// It returns the offset of the differing byte, the bit mask and the
+// direction of the other key. differ is false if the keys are equal.
+func critbit(key, other []byte) (off int, bit, odir byte, differ bool) {
+	var ch byte
+	var klen = len(key)
+	var olen = len(other)
+	// find differing byte
+	for off = 0; off < klen; off++ {
+		if ch = 0; off < olen {
+			ch = other[off]
+		}
+		if keych := key[off]; ch != keych {
+			bit = ch ^ keych
+			goto ByteFound
+		}
+	}
+	if off < olen {
+		ch = other[off]
+		bit = ch
+		goto ByteFound
 	}
-	println(indent, "Right: off=", node.off, "bit=", node.bit, "key=", string(node.child[1].Key))
-	if node.child[1].node != nil {
-		t.debug_dump(node.child[1].node, indent + "  ")
+	return
+ByteFound:
+	// find differing bit
+	bit |= bit >> 1
+	bit |= bit >> 2
+	bit |= bit >> 4
+	bit = bit &^ (bit >> 1)
+	if ch&bit != 0 {
+		odir = 1
 	}
+	differ = bit != 0
+	return
 }
 
 
@@ -363,4 +580,3 @@ func (v CountedKeySlice) Less(i, j int) bool {
 	}
 	return v[i].Count > v[j].Count  // inverted logic
 }
-
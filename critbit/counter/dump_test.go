@@ -0,0 +1,56 @@
+package counter
+
+import "testing"
+import "bytes"
+
+func Test_Dump(t *testing.T) {
+	tr := NewCounter(CountedKey{[]byte("a"), 1}, CountedKey{[]byte("b"), 2}, CountedKey{[]byte("c\x00"), 3})
+
+	tests := []struct {
+		opts DumpOpts
+		exp  string
+	}{
+		{DumpOpts{},
+			"T: NODE off=0 mask=00000010\n" +
+			"  L: LEAF byte=0x61 [01100001] key=\"a\" count=1\n" +
+			"  R: NODE off=0 mask=00000001\n" +
+			"    L: LEAF byte=0x62 [01100010] key=\"b\" count=2\n" +
+			"    R: LEAF byte=0x63 [01100011] key=\"c\\x00\" count=3\n"},
+		{DumpOpts{MaxDepth: 1, HideCounts: true},
+			"T: NODE off=0 mask=00000010\n" +
+			"  L: LEAF byte=0x61 [01100001] key=\"a\"\n" +
+			"  R: NODE off=0 mask=00000001\n" +
+			"    ...\n"},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		if err := tr.Dump(&buf, test.opts); err != nil || buf.String() != test.exp {
+			t.Errorf("test %d: got (%v)\n%s", i, err, buf.String())
+		}
+	}
+	var buf bytes.Buffer
+	if NewCounter().Dump(&buf, DumpOpts{}); buf.String() != "T: EMPTY\n" {
+		t.Errorf("got %q for an empty counter", buf.String())
+	}
+}
+
+func Test_WriteDOT(t *testing.T) {
+	tr := NewCounter(CountedKey{[]byte("a"), 1}, CountedKey{[]byte("b"), 2}, CountedKey{[]byte("c\x00"), 3})
+	exp := `digraph counter {
+	node [fontname="monospace"];
+	n0 [shape=ellipse, label="off=0 mask=00000010"];
+	n1 [shape=box, label="\"a\"\n1"];
+	n0 -> n1 [label="0"];
+	n2 [shape=ellipse, label="off=0 mask=00000001"];
+	n3 [shape=box, label="\"b\"\n2"];
+	n2 -> n3 [label="0"];
+	n4 [shape=box, label="\"c\\x00\"\n3"];
+	n2 -> n4 [label="1"];
+	n0 -> n2 [label="1"];
+}
+`
+	var buf bytes.Buffer
+	if err := tr.WriteDOT(&buf); err != nil || buf.String() != exp {
+		t.Errorf("got (%v)\n%s", err, buf.String())
+	}
+}
@@ -0,0 +1,49 @@
+package counter
+
+import "analyzers/lib/critbit/arena"
+
+
+// SetKeyMode makes the counter store keys of subsequent insertions according to
+// a mode (see the arena package). Keys stored before are not affected, use
+// Compact to copy them. Returns itself.
+func (t *Counter) SetKeyMode(mode arena.Mode) *Counter {
+	t.arena.SetMode(mode)
+	return t
+}
+
+// IncByCopy is like IncBy but stores a copy of a new key regardless of the mode.
+func (t *Counter) IncByCopy(key []byte, delta int) int {
+	return t.replace(key, func(prev int) int {return prev + delta}, true) + delta
+}
+
+// Compact copies all keys into fresh arena blocks, so the space taken by
+// deleted keys is released and no key is shared with the caller anymore.
+// It only works in the arena.Copy mode and does nothing otherwise.
+func (t *Counter) Compact() {
+	if t.Empty() {
+		return
+	}
+	t.arena.Compact(func(move func([]byte) []byte) {
+		moveKeys(&t.root, move)
+	})
+}
+
+// CheckKeys returns an error if a key has been modified after insertion.
+// Mutations are only detected in the arena.Debug mode.
+func (t *Counter) CheckKeys() error {
+	return t.arena.Check(func(visit func([]byte) bool) {
+		t.Iter(nil, func(ckey CountedKey) bool {
+			return visit(ckey.Key)
+		})
+	})
+}
+
+// moveKeys replaces all keys of the subtree at p with their moved versions
+func moveKeys(p *Ref, move func([]byte) []byte) {
+	if p.node == nil {
+		p.Key = move(p.Key)
+		return
+	}
+	moveKeys(&p.node.child[0], move)
+	moveKeys(&p.node.child[1], move)
+}
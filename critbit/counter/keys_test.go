@@ -0,0 +1,27 @@
+package counter
+
+import "testing"
+import "analyzers/lib/critbit/arena"
+
+func Test_KeyModes(t *testing.T) {
+	buf := []byte("aa")
+	tr := NewCounter().SetKeyMode(arena.Debug)
+	tr.Inc(buf)
+	tr.IncByCopy(buf, 2)
+	if c := tr.Get([]byte("aa")); c != 3 {
+		t.Errorf("wrong count %d", c)
+	}
+	buf[1] = 'b'
+	if err := tr.CheckKeys(); err == nil {
+		t.Error("CheckKeys must detect a modified key")
+	}
+
+	tr = NewCounter()
+	copy(buf, "aa")
+	tr.IncByCopy(buf, 2)
+	copy(buf, "zz")
+	tr.IncByCopy(buf, 1)
+	if keys := tr.Keys(); len(keys) != 2 || string(keys[0]) != "aa" {
+		t.Errorf("IncByCopy must store copies of keys, got %q", keys)
+	}
+}
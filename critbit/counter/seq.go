@@ -0,0 +1,52 @@
+package counter
+
+import "bytes"
+import "iter"
+
+
+// All returns an iterator over all keys and their counts in key order.
+func (t *Counter) All() iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		t.Iter(nil, func(ckey CountedKey) bool {
+			return yield(ckey.Key, ckey.Count)
+		})
+	}
+}
+
+// Prefix returns an iterator over all keys with a given prefix and their
+// counts in key order.
+func (t *Counter) Prefix(prefix []byte) iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		t.Iter(prefix, func(ckey CountedKey) bool {
+			return yield(ckey.Key, ckey.Count)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and their counts in reverse
+// key order.
+func (t *Counter) Backward() iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		t.IterReverse(nil, func(ckey CountedKey) bool {
+			return yield(ckey.Key, ckey.Count)
+		})
+	}
+}
+
+// Range returns an iterator over keys in [lo, hi) and their counts in key
+// order. A nil bound is unbounded.
+func (t *Counter) Range(lo, hi []byte) iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		h := func(ckey CountedKey) bool {
+			if hi != nil && bytes.Compare(ckey.Key, hi) >= 0 {
+				return false
+			}
+			return yield(ckey.Key, ckey.Count)
+		}
+		if lo == nil {
+			t.Iter(nil, h)
+		} else {
+			t.iterateFrom(lo, h)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package counter
+
+import "io"
+import "bufio"
+import "bytes"
+import "errors"
+import "fmt"
+import "strconv"
+import "encoding/json"
+import "unicode/utf8"
+
+
+// Keys are arbitrary bytes, so the text formats escape them: a backslash
+// becomes \\ while control characters and bytes of invalid UTF-8 sequences
+// become \xNN. Everything else is kept as is.
+
+var errEmptyKey = errors.New("empty key")
+
+// MarshalJSON implements json.Marshaler. A counter is an object of counts
+// with keys in key order.
+func (t *Counter) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	t.Iter(nil, func(ckey CountedKey) bool {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = appendQuoted(buf, ckey.Key)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(ckey.Count), 10)
+		return true
+	})
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the counter content.
+func (t *Counter) UnmarshalJSON(data []byte) error {
+	var obj map[string]int
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	tmp := Counter{arena: t.arena.Fork()}
+	for s, count := range obj {
+		key, err := unescapeKey([]byte(s))
+		if err != nil {
+			return fmt.Errorf("counter: key %q: %w", s, err)
+		}
+		tmp.Set(key, count)
+	}
+	*t = tmp
+	return nil
+}
+
+// WriteTSV writes all keys in key order, one escaped key and its count per line.
+func (t *Counter) WriteTSV(w io.Writer) (err error) {
+	bw  := bufio.NewWriter(w)
+	var line []byte
+	t.Iter(nil, func(ckey CountedKey) bool {
+		line = append(escapeKey(line[:0], ckey.Key), '\t')
+		line = append(strconv.AppendInt(line, int64(ckey.Count), 10), '\n')
+		_, err = bw.Write(line)
+		return err == nil
+	})
+	if err == nil {
+		err = bw.Flush()
+	}
+	return
+}
+
+// ReadTSV replaces the counter content with keys and counts read from r,
+// one per line.
+func (t *Counter) ReadTSV(r io.Reader) error {
+	tmp := Counter{arena: t.arena.Fork()}
+	err := readLines(r, func(line []byte) error {
+		k, v, found := bytes.Cut(line, []byte{'\t'})
+		if ! found {
+			return errors.New("missing count")
+		}
+		count, err := strconv.Atoi(string(v))
+		if err != nil {
+			return err
+		}
+		key, err := unescapeKey(k)
+		if err == nil {
+			tmp.Set(key, count)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	*t = tmp
+	return nil
+}
+
+// readLines calls a handler for every line of r without its terminator
+func readLines(r io.Reader, h func([]byte) error) error {
+	br := bufio.NewReader(r)
+	for num := 1; ; num++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return nil
+		}
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if herr := h(line); herr != nil {
+			return fmt.Errorf("counter: line %d: %w", num, herr)
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// escapeKey appends an escaped key to dst
+func escapeKey(dst, key []byte) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(key); {
+		c := key[i]
+		n := 1
+		switch {
+		case c == '\\':
+			dst = append(dst, '\\', '\\')
+		case c < 0x20 || c == 0x7f:
+			dst = append(dst, '\\', 'x', hex[c>>4], hex[c&15])
+		case c < utf8.RuneSelf:
+			dst = append(dst, c)
+		default:
+			if _, n = utf8.DecodeRune(key[i:]); n == 1 {
+				dst = append(dst, '\\', 'x', hex[c>>4], hex[c&15])
+			} else {
+				dst = append(dst, key[i:i+n]...)
+			}
+		}
+		i += n
+	}
+	return dst
+}
+
+// unescapeKey returns a new key decoded from an escaped one. Only the
+// escaping of escapeKey is accepted, so distinct strings never decode to the
+// same key.
+func unescapeKey(s []byte) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, errEmptyKey
+	}
+	key := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			key = append(key, c)
+			continue
+		}
+		switch {
+		case i+1 < len(s) && s[i+1] == '\\':
+			key = append(key, '\\')
+			i++
+		case i+3 < len(s) && s[i+1] == 'x':
+			b, err := strconv.ParseUint(string(s[i+2:i+4]), 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("bad escape sequence %q", s[i:i+4])
+			}
+			key = append(key, byte(b))
+			i += 3
+		default:
+			return nil, fmt.Errorf("bad escape sequence at %d", i)
+		}
+	}
+	if ! bytes.Equal(escapeKey(nil, key), s) {
+		return nil, fmt.Errorf("non-canonical escaping of key %q", key)
+	}
+	return key, nil
+}
+
+// appendQuoted appends an escaped key as a JSON string to dst
+func appendQuoted(dst, key []byte) []byte {
+	dst = append(dst, '"')
+	for _, c := range escapeKey(nil, key) {
+		if c == '"' || c == '\\' {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, '"')
+}
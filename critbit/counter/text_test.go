@@ -0,0 +1,95 @@
+package counter
+
+import "testing"
+import "bytes"
+import "strings"
+import "encoding/json"
+import "analyzers/lib/critbit/arena"
+
+var textKeys = []string{"b", "a\\b", "a\tb", "\xff\x01", "ключ", "a\"b"}
+
+func testCountsEq(a, b CountedKeySlice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if ! bytes.Equal(a[i].Key, b[i].Key) || a[i].Count != b[i].Count {
+			return false
+		}
+	}
+	return true
+}
+
+func items(tr *Counter) (s CountedKeySlice) {
+	tr.Iter(nil, func(ckey CountedKey) bool {
+		s = append(s, ckey)
+		return true
+	})
+	return
+}
+
+func Test_JSON(t *testing.T) {
+	tr := NewCounter()
+	for i, s := range textKeys {
+		tr.Set([]byte(s), i - 1)
+	}
+	data, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"a\\x09b":1,"a\"b":4,"a\\\\b":0,"b":-1,"ключ":3,"\\xff\\x01":2}`
+	if string(data) != expected {
+		t.Errorf("wrong JSON:\n  got %s\n  exp %s", data, expected)
+	}
+	res := NewCounter(CountedKey{[]byte("stale"), 1})
+	if err := json.Unmarshal(data, res); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if ! testCountsEq(items(res), items(tr)) {
+		t.Errorf("round-trip failed: %v", items(res))
+	}
+
+	for _, bad := range []string{`{"":1}`, `{"a\\":1}`, `{"a":"1"}`, `[]`, `{"\\x41":1,"A":2}`} {
+		if err := json.Unmarshal([]byte(bad), res); err == nil {
+			t.Errorf("Unmarshal(%s) must fail", bad)
+		}
+	}
+}
+
+func Test_TSV(t *testing.T) {
+	tr := NewCounter()
+	for i, s := range textKeys {
+		tr.Set([]byte(s), i - 1)
+	}
+	var buf bytes.Buffer
+	if err := tr.WriteTSV(&buf); err != nil {
+		t.Fatalf("WriteTSV failed: %v", err)
+	}
+	expected := "a\\x09b\t1\na\"b\t4\na\\\\b\t0\nb\t-1\nключ\t3\n\\xff\\x01\t2\n"
+	if buf.String() != expected {
+		t.Errorf("wrong TSV:\n  got %q\n  exp %q", buf.String(), expected)
+	}
+	res := NewCounter()
+	if err := res.ReadTSV(&buf); err != nil {
+		t.Fatalf("ReadTSV failed: %v", err)
+	}
+	if ! testCountsEq(items(res), items(tr)) {
+		t.Errorf("round-trip failed: %v", items(res))
+	}
+
+	for _, bad := range []string{"a\n", "a\tx\n", "\t1\n", "a\\q\t1\n", "\\x61\t1\n"} {
+		if err := res.ReadTSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadTSV(%q) must fail", bad)
+		}
+	}
+	if res.Len() != tr.Len() {
+		t.Errorf("failed ReadTSV must keep the counter intact, got %q", res.Keys())
+	}
+
+	// the key arena starts afresh in the same mode
+	res = NewCounter().SetKeyMode(arena.Copy)
+	res.Set([]byte("old"), 1)
+	if res.ReadTSV(strings.NewReader("")) != nil || res.arena.Size() != 0 || res.arena.Mode() != arena.Copy {
+		t.Errorf("ReadTSV must reset the key arena, size %d", res.arena.Size())
+	}
+}
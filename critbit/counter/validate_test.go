@@ -0,0 +1,37 @@
+package counter
+
+import "testing"
+import "strings"
+
+func Test_Validate(t *testing.T) {
+	newSet := func() *Counter {
+		return NewCounter(CountedKey{[]byte("abc"), 1}, CountedKey{[]byte("abd"), 2}, CountedKey{[]byte("b"), 3}, CountedKey{[]byte("ba"), 4})
+	}
+	for _, tr := range []*Counter{NewCounter(), NewCounter(CountedKey{[]byte("a"), 1}), newSet()} {
+		if err := tr.Validate(); err != nil {
+			t.Errorf("%q: unexpected error %v", tr.Keys(), err)
+		}
+	}
+
+	tests := []struct {
+		corrupt func(*Counter)
+		err     string
+	}{
+		// a key buffer reused after insertion
+		{func(tr *Counter) { tr.root.node.child[0].node.child[1].Key[2] = 'a' }, "not greater than the previous key"},
+		{func(tr *Counter) { tr.root.node.child[0].node.child[1].Key[1] = 'c' }, "instead of the crit bit"},
+		{func(tr *Counter) { c := &tr.root.node.child; c[0], c[1] = c[1], c[0] }, "wrong side"},
+		{func(tr *Counter) { tr.size++ }, "size is 5 but there are 4 keys"},
+		{func(tr *Counter) { tr.root.node.bit = 0x03 }, "no single crit bit"},
+		{func(tr *Counter) { tr.root.node.child[0].node.off = 0 }, "not below its parent"},
+		{func(tr *Counter) { tr.root.node.child[1].node.child[1].Key = []byte("b") }, "not greater than the previous key"},
+		{func(tr *Counter) { tr.root.node.child[0].node.child[0].Key = nil }, "empty key"},
+	}
+	for i, test := range tests {
+		tr := newSet()
+		test.corrupt(tr)
+		if err := tr.Validate(); err == nil || ! strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected %q error, got %v", i, test.err, err)
+		}
+	}
+}
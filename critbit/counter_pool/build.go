@@ -0,0 +1,110 @@
+package counter
+
+import "fmt"
+import "iter"
+
+
+// BuildSorted builds a counter of keys given in increasing order in linear
+// time taking nodes from a pool (a new one if nil). It fails on out-of-order
+// and duplicate keys.
+func BuildSorted(pool *NodePool, ckeys CountedKeySlice) (*Counter, error) {
+	b := newBuilder(pool)
+	for _, ckey := range ckeys {
+		if err := b.add(ckey.Key, ckey.Count); err != nil {
+			b.abort()
+			return nil, err
+		}
+	}
+	return b.finish(), nil
+}
+
+// BuildSortedSeq is like BuildSorted but takes keys and counts from an iterator.
+func BuildSortedSeq(pool *NodePool, seq iter.Seq2[[]byte, int]) (*Counter, error) {
+	b := newBuilder(pool)
+	for key, count := range seq {
+		if err := b.add(key, count); err != nil {
+			b.abort()
+			return nil, err
+		}
+	}
+	return b.finish(), nil
+}
+
+
+// builder assembles a tree bottom-up from keys coming in increasing order.
+// It keeps the right spine of the tree built so far, so that every key is
+// linked in amortized O(1) time.
+type builder struct {
+	t     *Counter
+	// spine holds indices of the nodes on the right edge of the tree
+	// (from the root down), the last key is the right child of the last one
+	spine []int
+}
+
+func newBuilder(pool *NodePool) *builder {
+	return &builder{t: NewCounter(pool)}
+}
+
+// ref returns the Ref at a given spine level (0 is the root)
+func (b *builder) ref(level int) *Ref {
+	if level == 0 {
+		return &b.t.root
+	}
+	return &b.t.pool.Nodes[b.spine[level-1]].child[1]
+}
+
+// add appends a key which must be greater than all keys added before.
+func (b *builder) add(key []byte, count int) error {
+	t := b.t
+	if len(key) == 0 {
+		return fmt.Errorf("counter: empty key at position %d", t.size)
+	}
+	if t.size == 0 {
+		t.root.CountedKey = CountedKey{key, count}
+		t.size++
+		return nil
+	}
+	last := b.ref(len(b.spine))
+	off, bit, ldir, differ := critbit(key, last.Key)
+	if ! differ {
+		return fmt.Errorf("counter: duplicate key %q at position %d", key, t.size)
+	}
+	if ldir == 1 {
+		return fmt.Errorf("counter: key %q at position %d is less than the previous key %q", key, t.size, last.Key)
+	}
+	// ascend the spine while the nodes are below the new crit bit
+	i := len(b.spine)
+	for i > 0 {
+		n_ptr := &t.pool.Nodes[b.spine[i-1]]
+		if n_ptr.off < off || n_ptr.off == off && n_ptr.bit > bit {
+			break
+		}
+		i--
+	}
+	// insert new node (the new key always goes right)
+	nn_idx := t.pool.GetNode()
+	nn_ptr := &t.pool.Nodes[nn_idx]
+	*nn_ptr = Node{off:off, bit:bit, child:[2]Ref{EMPTY_REF, EMPTY_REF}}
+	nn_ptr.child[1].CountedKey = CountedKey{key, count}
+
+	wp := b.ref(i)
+	nn_ptr.child[0] = *wp
+	wp.index = nn_idx
+	wp.CountedKey = CountedKey{}
+
+	b.spine = append(b.spine[:i], nn_idx)
+	t.size++
+	return nil
+}
+
+// finish returns the counter built
+func (b *builder) finish() *Counter {
+	return b.t
+}
+
+// abort puts the nodes taken so far back to the pool
+func (b *builder) abort() {
+	if ! b.t.Empty() {
+		b.t.free(b.t.root)
+	}
+}
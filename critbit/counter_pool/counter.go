@@ -1,8 +1,8 @@
 package counter
 
-import "fmt"
 import "sort"
 import "bytes"
+import "analyzers/lib/critbit/arena"
 
 
 
@@ -34,6 +34,8 @@ type Counter struct {
 	size int
 	root Ref
 	pool *NodePool
+	// arena stores the keys (the zero value stores them as passed)
+	arena arena.Keys
 }
 
 // dir calculates the direction for the given key
@@ -52,6 +54,7 @@ func InitCounter(counter *Counter, pool *NodePool, counted_keys ...CountedKey) *
 		size : 0,
 		root : EMPTY_REF,
 		pool : pool,
+		arena: counter.arena,
 	}
 	for _, ckey := range counted_keys {
 		counter.IncBy(ckey.Key, ckey.Count)
@@ -107,7 +110,7 @@ func (t *Counter) Set(key []byte, count int) int {
 
 	// test for empty tree
 	if t.Empty() {
-		t.root.Key   = key
+		t.root.Key   = t.arena.Store(key, false)
 		t.root.Count = count
 		t.size++
 		return 0
@@ -155,7 +158,7 @@ ByteFound:
 	nn_idx := t.pool.GetNode()
 	nn_ptr := &t.pool.Nodes[nn_idx]
 	*nn_ptr = Node{off:off, bit:bit, child:[2]Ref{EMPTY_REF, EMPTY_REF}}
-	nn_ptr.child[1-ndir].CountedKey = CountedKey{key, count}
+	nn_ptr.child[1-ndir].CountedKey = CountedKey{t.arena.Store(key, false), count}
 
 	// walk for best insertion node
 	wp := &t.root
@@ -177,9 +180,14 @@ ByteFound:
 
 // IncBy incremets a count associated with the key by a given delta and returns it.
 func (t *Counter) IncBy(key []byte, delta int) (count int) {
+	return t.incBy(key, delta, false)
+}
+
+// incBy increments a count storing a copy of a new key if necessary
+func (t *Counter) incBy(key []byte, delta int, copy bool) (count int) {
 	// test for empty tree
 	if t.Empty() {
-		t.root.Key   = key
+		t.root.Key   = t.arena.Store(key, copy)
 		t.root.Count = delta
 		t.size++
 		return delta
@@ -226,7 +234,7 @@ ByteFound:
 	nn_idx := t.pool.GetNode()
 	nn_ptr := &t.pool.Nodes[nn_idx]
 	*nn_ptr = Node{off:off, bit:bit, child:[2]Ref{EMPTY_REF, EMPTY_REF}}
-	nn_ptr.child[1-ndir].CountedKey = CountedKey{key, delta}
+	nn_ptr.child[1-ndir].CountedKey = CountedKey{t.arena.Store(key, copy), delta}
 
 	// walk for best insertion node
 	wp := &t.root
@@ -286,6 +294,7 @@ func (t *Counter) Del(key []byte) (count int) {
 	count = p.Count
 	// delete from the tree
 	t.size--
+	t.arena.Release(p.Key)
 	if wp == nil {
 		count = t.root.Count
 		if t.root.index >= 0 {
@@ -300,6 +309,32 @@ func (t *Counter) Del(key []byte) (count int) {
 	return
 }
 
+// DeletePrefix removes all keys with a given prefix and returns their number.
+// The whole subtree is unlinked at once, but its nodes are put back to the
+// pool one by one, so it takes O(k) time for k removed keys.
+func (t *Counter) DeletePrefix(prefix []byte) int {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return 0
+	}
+	sub := *top
+	t.unlink(top, parent)
+	n := t.free(sub)
+	t.size -= n
+	return n
+}
+
+// free puts all nodes of a subtree back to the pool and returns the number of keys
+func (t *Counter) free(p Ref) int {
+	if p.index == -1 {
+		t.arena.Release(p.Key)
+		return 1
+	}
+	node := t.pool.Nodes[p.index]
+	t.pool.PutNode(p.index)
+	return t.free(node.child[0]) + t.free(node.child[1])
+}
+
 // Merge merges another Counter into this one. Counters of common keys are added up.
 // Returns itself.
 func (t *Counter) Merge(other *Counter, prefix []byte) *Counter {
@@ -313,51 +348,193 @@ func (t *Counter) Merge(other *Counter, prefix []byte) *Counter {
 	return t
 }
 
-// Iter calls a handler for all keys with a given prefix.
-// It returns whether all prefixed keys were iterated.
-// The handler can continue the process by returning true or abort with false.
-func (t *Counter) Iter(prefix []byte, handler func(CountedKey) bool) bool {
-	// test empty tree
-	if t.root.index == -1 && len(t.root.Key) == 0 {
-		return true
+// Min returns the smallest counted key with a given prefix.
+func (t *Counter) Min(prefix []byte) (ckey CountedKey, ok bool) {
+	if leaf, _ := t.edge(prefix, 0); leaf != nil {
+		return leaf.CountedKey, true
 	}
-	// shortcut for empty prefix
-	if len(prefix) == 0 {
-		return t.iterate(t.root, handler)
+	return
+}
+
+// Max returns the greatest counted key with a given prefix.
+func (t *Counter) Max(prefix []byte) (ckey CountedKey, ok bool) {
+	if leaf, _ := t.edge(prefix, 1); leaf != nil {
+		return leaf.CountedKey, true
+	}
+	return
+}
+
+// PopMin removes the smallest counted key with a given prefix and returns it.
+func (t *Counter) PopMin(prefix []byte) (ckey CountedKey, ok bool) {
+	return t.pop(prefix, 0)
+}
+
+// PopMax removes the greatest counted key with a given prefix and returns it.
+func (t *Counter) PopMax(prefix []byte) (ckey CountedKey, ok bool) {
+	return t.pop(prefix, 1)
+}
+
+// pop removes the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix.
+func (t *Counter) pop(prefix []byte, dir byte) (ckey CountedKey, ok bool) {
+	leaf, parent := t.edge(prefix, dir)
+	if leaf == nil {
+		return
+	}
+	ckey, ok = leaf.CountedKey, true
+	// delete from the tree
+	t.size--
+	t.arena.Release(ckey.Key)
+	t.unlink(leaf, parent)
+	return
+}
+
+// edge finds the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix
+// along with the Ref of its parent node.
+func (t *Counter) edge(prefix []byte, dir byte) (leaf, parent *Ref) {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return nil, nil
+	}
+	leaf = top
+	for leaf.index != -1 {
+		parent = leaf
+		leaf = &t.pool.Nodes[leaf.index].child[dir]
+	}
+	return
+}
+
+// unlink removes the subtree at ref from its parent node (nil for the root)
+// and puts the parent node back to the pool.
+func (t *Counter) unlink(ref, parent *Ref) {
+	if parent == nil {
+		t.root = EMPTY_REF
+		return
+	}
+	idx := parent.index
+	n_ptr := &t.pool.Nodes[idx]
+	if &n_ptr.child[0] == ref {
+		*parent = n_ptr.child[1]
+	} else {
+		*parent = n_ptr.child[0]
+	}
+	t.pool.PutNode(idx)
+}
+
+// findTop returns the topmost Ref holding all keys with a given prefix
+// (nil if there are no such keys) along with the Ref of its parent node.
+func (t *Counter) findTop(prefix []byte) (top, parent *Ref) {
+	// test empty tree
+	if t.Empty() {
+		return
 	}
 	// walk for best member
-	p, top := t.root, t.root
+	p := &t.root
+	top = p
 	for p.index != -1 {
-		node := t.pool.Nodes[p.index]
-		newtop := node.off < len(prefix)
+		n_ptr  := &t.pool.Nodes[p.index]
+		newtop := n_ptr.off < len(prefix)
+		wp := p
 		// try next node
-		p = node.child[node.dir(prefix)]
+		p = &n_ptr.child[n_ptr.dir(prefix)]
 		if newtop {
-			top = p
+			top, parent = p, wp
 		}
 	}
 	if len(p.Key) < len(prefix) {
-		return true
+		return nil, nil
 	}
 	for i := 0; i < len(prefix); i++ {
 		if p.Key[i] != prefix[i] {
-			return true
+			return nil, nil
 		}
 	}
-	return t.iterate(top, handler)
+	return
+}
+
+// Iter calls a handler for all keys with a given prefix.
+// It returns whether all prefixed keys were iterated.
+// The handler can continue the process by returning true or abort with false.
+func (t *Counter) Iter(prefix []byte, handler func(CountedKey) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 0, handler)
+	}
+	return true
+}
+
+// IterReverse is like Iter but calls the handler in a reverse key order.
+func (t *Counter) IterReverse(prefix []byte, handler func(CountedKey) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 1, handler)
+	}
+	return true
 }
 
 // iterate calls the key handler or traverses both node children unless aborted.
-func (t *Counter) iterate(p Ref, h func(CountedKey) bool) bool {
+// The children are visited in key order (rev=0) or in reverse order (rev=1).
+func (t *Counter) iterate(p Ref, rev byte, h func(CountedKey) bool) bool {
 	if p.index != -1 {
 		node := t.pool.Nodes[p.index]
-		return t.iterate(node.child[0], h) && t.iterate(node.child[1], h)
+		return t.iterate(node.child[rev], rev, h) && t.iterate(node.child[1-rev], rev, h)
 	}
 	return h(p.CountedKey)
 }
 
+// iterateFrom calls the key handler for all keys greater-or-equal to lo in key order.
+// It descends once along lo collecting the right siblings to visit afterwards.
+func (t *Counter) iterateFrom(lo []byte, h func(CountedKey) bool) bool {
+	// test empty tree
+	if t.Empty() {
+		return true
+	}
+	// walk for best member
+	p := t.root
+	for p.index != -1 {
+		// try next node
+		n := t.pool.Nodes[p.index]
+		p = n.child[n.dir(lo)]
+	}
+	off, bit, pdir, differ := critbit(lo, p.Key)
+
+	// walk down to the insertion point of lo remembering greater siblings
+	var rest []Ref
+	wp := t.root
+	for wp.index != -1 {
+		n := t.pool.Nodes[wp.index]
+		if differ && (n.off > off || n.off == off && n.bit < bit) {
+			break
+		}
+		dir := n.dir(lo)
+		if dir == 0 {
+			rest = append(rest, n.child[1])
+		}
+		wp = n.child[dir]
+	}
+	// the subtree at the insertion point is either all greater or all lesser
+	if ! differ || pdir == 1 {
+		if ! t.iterate(wp, 0, h) {
+			return false
+		}
+	}
+	for i := len(rest) - 1; i >= 0; i-- {
+		if ! t.iterate(rest[i], 0, h) {
+			return false
+		}
+	}
+	return true
+}
+
 // Keys returns all keys, as a slice of []byte, in a sorted order.
 func (t *Counter) Keys() [][]byte {
+	return t.keys(0)
+}
+
+// KeysReverse returns all keys, as a slice of []byte, in a reverse sorted order.
+func (t *Counter) KeysReverse() [][]byte {
+	return t.keys(1)
+}
+
+// keys collects all keys in key order (rev=0) or in reverse order (rev=1).
+func (t *Counter) keys(rev byte) [][]byte {
 	keys := make([][]byte, 0, t.size)
 
 	// empty tree?
@@ -384,7 +561,7 @@ func (t *Counter) Keys() [][]byte {
 		} else {
 			// unshift the children and continue
 			node := t.pool.Nodes[p.index]
-			to_visit = append(to_visit, &node.child[1], &node.child[0])
+			to_visit = append(to_visit, &node.child[1-rev], &node.child[rev])
 		}
 	}
 	return keys
@@ -427,17 +604,40 @@ func (t *Counter) CountedKeys() CountedKeySlice {
 	return pairs
 }
 
-func (t *Counter) debug_dump(idx int, indent string) {
-	n := t.pool.Nodes[idx]
-	fmt.Println(indent, "NODE", idx, n)
-	println(indent, "Left:  off=", n.off, "bit=", n.bit, "key=", string(n.child[0].Key))
-	if n.child[0].index != -1 {
-		t.debug_dump(n.child[0].index, indent + "  ")
+// critbit finds the first bit the key differs from another key in.
+// It returns the offset of the differing byte, the bit mask and the
+// direction of the other key. differ is false if the keys are equal.
+func critbit(key, other []byte) (off int, bit, odir byte, differ bool) {
+	var ch byte
+	var klen = len(key)
+	var olen = len(other)
+	// find differing byte
+	for off = 0; off < klen; off++ {
+		if ch = 0; off < olen {
+			ch = other[off]
+		}
+		if keych := key[off]; ch != keych {
+			bit = ch ^ keych
+			goto ByteFound
+		}
 	}
-	println(indent, "Right: off=", n.off, "bit=", n.bit, "key=", string(n.child[1].Key))
-	if n.child[1].index != -1 {
-		t.debug_dump(n.child[1].index, indent + "  ")
+	if off < olen {
+		ch = other[off]
+		bit = ch
+		goto ByteFound
 	}
+	return
+ByteFound:
+	// find differing bit
+	bit |= bit >> 1
+	bit |= bit >> 2
+	bit |= bit >> 4
+	bit = bit &^ (bit >> 1)
+	if ch&bit != 0 {
+		odir = 1
+	}
+	differ = bit != 0
+	return
 }
 
 
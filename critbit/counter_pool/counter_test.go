@@ -235,3 +235,160 @@ func Test_Merge(t *testing.T) {
 		}
 	}
 }
+
+func Test_IterReverse(t *testing.T) {
+	tr := NewCounter(nil)
+	keys := []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}
+
+	for _, s := range keys {
+		tr.Inc([]byte(s))
+	}
+	tests := []struct {
+		prefix string
+		keys   []string
+	}{
+		{"", []string{"bbb", "bba", "bb", "ba", "ab", "aab", "aaa", "aa"}},
+		{"a", []string{"ab", "aab", "aaa", "aa"}},
+		{"bb", []string{"bbb", "bba", "bb"}},
+		{"aaa", []string{"aaa"}},
+		{"aaaa", nil},
+		{"c", nil},
+	}
+	for i, test := range tests {
+		s := test.keys
+		tr.IterReverse([]byte(test.prefix), func(ckey CountedKey) bool {
+			if len(s) < 1 {
+				t.Errorf("test %d: superfluous key %q", i, string(ckey.Key))
+				return true
+			}
+			if ! bytes.Equal([]byte(s[0]), ckey.Key) {
+				t.Errorf("test %d: got key %q, expected %q", i, string(ckey.Key), s[0])
+			}
+			s = s[1:]
+			return true
+		})
+		if len(s) > 0 {
+			t.Errorf("test %d: missing keys %q", i, s)
+		}
+	}
+
+	// abort after two keys
+	n := 0
+	if tr.IterReverse(nil, func(ckey CountedKey) bool { n++; return n < 2 }) || n != 2 {
+		t.Errorf("IterReverse must stop when the handler returns false (%d calls)", n)
+	}
+}
+
+func Test_KeysReverse(t *testing.T) {
+	tr := NewCounter(nil)
+	orig_keys := []string{"zz", "dd", "yy", "cc", "xx", "bb", "ww", "aa"}
+	expected := [][]byte{
+		[]byte("zz"), []byte("yy"), []byte("xx"), []byte("ww"),
+		[]byte("dd"), []byte("cc"), []byte("bb"), []byte("aa")}
+
+	if returned_keys := tr.KeysReverse(); len(returned_keys) != 0 {
+		t.Errorf("Got: %q", returned_keys)
+	}
+	for _, s := range orig_keys {
+		tr.Inc([]byte(s))
+	}
+	returned_keys := tr.KeysReverse()
+	if ! testKeysEq(returned_keys, expected) {
+		t.Errorf("Got: %q", returned_keys)
+	}
+}
+
+func Test_MinMax(t *testing.T) {
+	tr := NewCounter(nil)
+	if _, ok := tr.Min(nil); ok {
+		t.Error("Min() of an empty tree must fail")
+	}
+	if _, ok := tr.PopMax(nil); ok {
+		t.Error("PopMax() of an empty tree must fail")
+	}
+	for _, s := range []string{"ba", "aab", "bbb", "aa", "ab", "bb", "aaa", "bba"} {
+		tr.IncBy([]byte(s), len(s))
+	}
+	tests := []struct {
+		prefix   string
+		min, max string
+	}{
+		{"",    "aa",  "bbb"},
+		{"a",   "aa",  "ab"},
+		{"aa",  "aa",  "aab"},
+		{"b",   "ba",  "bbb"},
+		{"bba", "bba", "bba"},
+		{"c",   "",    ""},
+		{"aac", "",    ""},
+	}
+	for i, test := range tests {
+		if ckey, _ := tr.Min([]byte(test.prefix)); string(ckey.Key) != test.min {
+			t.Errorf("test %d: Min(%q) -> %q, expected %q", i, test.prefix, ckey.Key, test.min)
+		}
+		if ckey, _ := tr.Max([]byte(test.prefix)); string(ckey.Key) != test.max {
+			t.Errorf("test %d: Max(%q) -> %q, expected %q", i, test.prefix, ckey.Key, test.max)
+		}
+	}
+
+	// pop the greatest "a..." keys, then drain the rest from the left
+	expected := []string{"ab", "aab", "aaa", "aa", "ba", "bb", "bba", "bbb"}
+	for i, exp := range expected {
+		pop := tr.PopMin
+		if i < 4 {
+			pop = tr.PopMax
+		}
+		prefix := []byte("a")
+		if i >= 4 {
+			prefix = nil
+		}
+		ckey, ok := pop(prefix)
+		if ! ok || string(ckey.Key) != exp {
+			t.Errorf("pop %d: got (%q, %v), expected %q", i, ckey.Key, ok, exp)
+		}
+		if ckey.Count != len(ckey.Key) {
+			t.Errorf("wrong count of %q: %v", ckey.Key, ckey.Count)
+		}
+		if tr.Len() != len(expected) - i - 1 {
+			t.Errorf("pop %d: wrong length %d", i, tr.Len())
+		}
+	}
+	if ! tr.Empty() {
+		t.Errorf("the tree must be empty, got %q", tr.Keys())
+	}
+}
+
+func Test_DeletePrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		n      int
+		rest   []string
+	}{
+		{"c",    0, []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}},
+		{"aac",  0, []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}},
+		{"aa",   3, []string{"ab", "ba", "bb", "bba", "bbb"}},
+		{"bba",  1, []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bbb"}},
+		{"b",    4, []string{"aa", "aaa", "aab", "ab"}},
+		{"",     8, nil},
+	}
+	for i, test := range tests {
+		pool := NewNodePool(0)
+		tr := NewCounter(pool)
+		for _, s := range []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"} {
+			tr.Inc([]byte(s))
+		}
+		if n := tr.DeletePrefix([]byte(test.prefix)); n != test.n {
+			t.Errorf("test %d: DeletePrefix(%q) -> %d, expected %d", i, test.prefix, n, test.n)
+		}
+		var expected [][]byte
+		for _, s := range test.rest {
+			expected = append(expected, []byte(s))
+		}
+		if keys := tr.Keys(); ! testKeysEq(keys, expected) || tr.Len() != len(expected) {
+			t.Errorf("test %d: got %q (len %d), expected %q", i, keys, tr.Len(), test.rest)
+		}
+		// all nodes but the ones of remaining keys are back in the pool
+		if live := len(pool.Nodes) - len(pool.FreeIdx); live != max(len(expected) - 1, 0) {
+			t.Errorf("test %d: %d nodes in use, expected %d", i, live, max(len(expected) - 1, 0))
+		}
+	}
+}
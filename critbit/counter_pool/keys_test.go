@@ -0,0 +1,34 @@
+package counter
+
+import "testing"
+import "analyzers/lib/critbit/arena"
+
+func Test_KeyModes(t *testing.T) {
+	buf := []byte("aa")
+	tr := NewCounter(nil).SetKeyMode(arena.Debug)
+	tr.Inc(buf)
+	tr.IncByCopy(buf, 2)
+	if c := tr.Get([]byte("aa")); c != 3 {
+		t.Errorf("wrong count %d", c)
+	}
+	buf[1] = 'b'
+	if err := tr.CheckKeys(); err == nil {
+		t.Error("CheckKeys must detect a modified key")
+	}
+	if tr.DeletePrefix([]byte("a")) != 1 || tr.CheckKeys() != nil {
+		t.Error("a removed key must not be checked")
+	}
+
+	// copied keys survive deletions and Compact
+	tr = NewCounter(nil).SetKeyMode(arena.Copy)
+	for i, s := range []string{"aa", "ab", "ba"} {
+		copy(buf, s)
+		tr.Set(buf, i)
+	}
+	tr.Del([]byte("ab"))
+	tr.Compact()
+	copy(buf, "AA")
+	if keys := tr.Keys(); len(keys) != 2 || string(keys[0]) != "aa" || string(keys[1]) != "ba" {
+		t.Errorf("copied keys must not change, got %q", keys)
+	}
+}
@@ -0,0 +1,33 @@
+package counter
+
+import "testing"
+import "slices"
+
+func Test_Stats(t *testing.T) {
+	pool := NewNodePool(8)
+	s := NewCounter(pool).Stats()
+	if s.Nodes != 0 || s.Leaves != 0 || len(s.Depths) != 0 || s.KeyBytes != 0 || s.HeapBytes() != s.RefBytes {
+		t.Errorf("wrong stats of an empty counter: %+v", s)
+	}
+
+	// 'a' differs from 'b' and 'c' in the 0x02 bit, 'b' and 'c' differ in the 0x01 bit
+	tr := NewCounter(pool, CountedKey{[]byte("a"), 1}, CountedKey{[]byte("b"), 2}, CountedKey{[]byte("c"), 3})
+	s = tr.Stats()
+	if s.Nodes != 2 || s.Leaves != 3 || s.KeyBytes != 3 {
+		t.Errorf("wrong counts: %+v", s)
+	}
+	if ! slices.Equal(s.Depths, []int{0, 1, 2}) || s.MaxDepth != 2 || s.AvgDepth != 5.0/3 {
+		t.Errorf("wrong depths: %+v", s)
+	}
+	if s.PoolNodes != 2 || s.PoolFree != 0 || s.PoolUtilization != 2.0/8 {
+		t.Errorf("wrong pool stats: %+v", s)
+	}
+
+	tr.Del([]byte("c"))
+	if s := tr.Stats(); s.Nodes != 1 || s.PoolNodes != 2 || s.PoolFree != 1 || s.PoolUtilization != 1.0/8 {
+		t.Errorf("wrong pool stats after Del: %+v", s)
+	}
+	if s := tr.Stats(); s.PoolBytes < 8 * s.NodeBytes {
+		t.Errorf("wrong pool memory: %+v", s)
+	}
+}
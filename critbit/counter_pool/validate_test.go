@@ -0,0 +1,43 @@
+package counter
+
+import "testing"
+import "strings"
+
+func Test_Validate(t *testing.T) {
+	newCounter := func() *Counter {
+		return NewCounter(nil, CountedKey{[]byte("abc"), 1}, CountedKey{[]byte("abd"), 2}, CountedKey{[]byte("b"), 3}, CountedKey{[]byte("ba"), 4})
+	}
+	for _, tr := range []*Counter{NewCounter(nil), NewCounter(nil, CountedKey{[]byte("a"), 1}), newCounter()} {
+		if err := tr.Validate(); err != nil {
+			t.Errorf("%q: unexpected error %v", tr.Keys(), err)
+		}
+	}
+	// node returns the node of a ref
+	node := func(tr *Counter, r Ref) *Node {
+		return &tr.pool.Nodes[r.index]
+	}
+
+	tests := []struct {
+		corrupt func(*Counter)
+		err     string
+	}{
+		// a key buffer reused after insertion
+		{func(tr *Counter) { node(tr, node(tr, tr.root).child[0]).child[1].Key[2] = 'a' }, "not greater than the previous key"},
+		{func(tr *Counter) { node(tr, node(tr, tr.root).child[0]).child[1].Key[1] = 'c' }, "instead of the crit bit"},
+		{func(tr *Counter) { c := &node(tr, tr.root).child; c[0], c[1] = c[1], c[0] }, "wrong side"},
+		{func(tr *Counter) { tr.size++ }, "size is 5 but there are 4 keys"},
+		{func(tr *Counter) { node(tr, tr.root).bit = 0x03 }, "no single crit bit"},
+		{func(tr *Counter) { node(tr, node(tr, tr.root).child[0]).off = 0 }, "not below its parent"},
+		{func(tr *Counter) { node(tr, tr.root).child[1].index = 99 }, "out of the pool"},
+		{func(tr *Counter) { node(tr, tr.root).child[1].index = tr.root.index }, "referenced twice"},
+		{func(tr *Counter) { tr.pool.FreeIdx = append(tr.pool.FreeIdx, node(tr, tr.root).child[0].index) }, "both live and in the free-list"},
+		{func(tr *Counter) { tr.pool.FreeIdx = append(tr.pool.FreeIdx, 99) }, "bad or repeated index 99 in the free-list"},
+	}
+	for i, test := range tests {
+		tr := newCounter()
+		test.corrupt(tr)
+		if err := tr.Validate(); err == nil || ! strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected %q error, got %v", i, test.err, err)
+		}
+	}
+}
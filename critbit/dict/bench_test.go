@@ -84,9 +84,9 @@ func BenchmarkTree(b *testing.B) {
 	var count int
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		t := NewDict()
+		t := NewDict[struct{}]()
 		for _, w := range words {
-			t.Set(StringToBytes(w), nil)
+			t.Set(StringToBytes(w), struct{}{})
 		}
 		count = 0
 		for _, w := range tests {
@@ -117,12 +117,12 @@ func BenchmarkTreeSort(b *testing.B) {
 	initdata(b)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		t := NewDict()
+		t := NewDict[struct{}]()
 		for _, w := range words {
-			t.Set(StringToBytes(w), nil)
+			t.Set(StringToBytes(w), struct{}{})
 		}
 		//s := make([]string, 0, t.Len())
-		t.Iter(nil, func(item Item) bool {
+		t.Iter(nil, func(item Item[struct{}]) bool {
 			//s = append(s, key)
 			return true
 		})
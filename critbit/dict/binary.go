@@ -0,0 +1,299 @@
+package dict
+
+import "io"
+import "bytes"
+import "bufio"
+import "errors"
+import "fmt"
+import "hash/crc32"
+import "encoding/binary"
+
+
+// Binary format (all integers are uvarints unless noted):
+//
+//	magic    "CBDT"
+//	version  1 byte
+//	count    number of items
+//	items    count x { shared prefix length, suffix length, suffix,
+//	                   value length, value }
+//	checksum CRC-32C of all the above, 4 bytes big-endian
+//
+// Keys come in sorted order and are front-coded: each key stores only the
+// length of the prefix it shares with the previous key and the rest.
+const (
+	binaryMagic   = "CBDT"
+	binaryVersion = 1
+)
+
+var (
+	ErrNoCodec     = errors.New("dict: no value codec")
+	ErrBadFormat   = errors.New("dict: malformed binary data")
+	ErrBadChecksum = errors.New("dict: checksum mismatch")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ValueCodec encodes and decodes Dict values for binary serialization.
+// DecodeValue must not retain src after returning.
+type ValueCodec[V any] interface {
+	AppendValue(dst []byte, val V) ([]byte, error)
+	DecodeValue(src []byte) (V, error)
+}
+
+// StringCodec stores string values as is
+type StringCodec struct{}
+
+func (StringCodec) AppendValue(dst []byte, val string) ([]byte, error) {
+	return append(dst, val...), nil
+}
+func (StringCodec) DecodeValue(src []byte) (string, error) {
+	return string(src), nil
+}
+
+// BytesCodec stores []byte values as is
+type BytesCodec struct{}
+
+func (BytesCodec) AppendValue(dst []byte, val []byte) ([]byte, error) {
+	return append(dst, val...), nil
+}
+func (BytesCodec) DecodeValue(src []byte) ([]byte, error) {
+	return bytes.Clone(src), nil
+}
+
+// IntCodec stores int values as varints
+type IntCodec struct{}
+
+func (IntCodec) AppendValue(dst []byte, val int) ([]byte, error) {
+	return binary.AppendVarint(dst, int64(val)), nil
+}
+func (IntCodec) DecodeValue(src []byte) (int, error) {
+	val, n := binary.Varint(src)
+	if n <= 0 || n != len(src) {
+		return 0, ErrBadFormat
+	}
+	return int(val), nil
+}
+
+// SetCodec sets a codec used by the binary serialization. Returns itself.
+// Dicts of string, []byte and int values have a default codec.
+func (t *Dict[V]) SetCodec(codec ValueCodec[V]) *Dict[V] {
+	t.codec = codec
+	return t
+}
+
+// valueCodec returns the codec set by SetCodec or a default one
+func (t *Dict[V]) valueCodec() (ValueCodec[V], error) {
+	if t.codec != nil {
+		return t.codec, nil
+	}
+	var zero V
+	switch any(zero).(type) {
+	case string:
+		return any(StringCodec{}).(ValueCodec[V]), nil
+	case []byte:
+		return any(BytesCodec{}).(ValueCodec[V]), nil
+	case int:
+		return any(IntCodec{}).(ValueCodec[V]), nil
+	}
+	return nil, ErrNoCodec
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (t *Dict[V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := t.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (t *Dict[V]) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	if _, err := t.ReadFrom(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrBadFormat, r.Len())
+	}
+	return nil
+}
+
+// WriteTo writes the dict in the binary format. It implements io.WriterTo.
+func (t *Dict[V]) WriteTo(w io.Writer) (n int64, err error) {
+	codec, err := t.valueCodec()
+	if err != nil {
+		return
+	}
+	cw  := &countWriter{w: w}
+	bw  := bufio.NewWriter(cw)
+	crc := crc32.New(crcTable)
+	out := io.MultiWriter(bw, crc)
+
+	rec := append([]byte(binaryMagic), binaryVersion)
+	rec  = binary.AppendUvarint(rec, uint64(t.size))
+	if _, err = out.Write(rec); err != nil {
+		return cw.n, err
+	}
+
+	var prev, val []byte
+	t.Iter(nil, func(item Item[V]) bool {
+		shared := 0
+		for shared < len(prev) && shared < len(item.Key) && prev[shared] == item.Key[shared] {
+			shared++
+		}
+		if val, err = codec.AppendValue(val[:0], item.Val); err != nil {
+			return false
+		}
+		rec = binary.AppendUvarint(rec[:0], uint64(shared))
+		rec = binary.AppendUvarint(rec, uint64(len(item.Key) - shared))
+		rec = append(rec, item.Key[shared:]...)
+		rec = binary.AppendUvarint(rec, uint64(len(val)))
+		rec = append(rec, val...)
+		_, err = out.Write(rec)
+		prev = item.Key
+		return err == nil
+	})
+	if err == nil {
+		_, err = bw.Write(crc.Sum(nil))
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	return cw.n, err
+}
+
+// ReadFrom replaces the dict content with the one read in the binary format.
+// The tree is rebuilt in one pass. If r does not implement io.ByteReader,
+// it is buffered and may be read past the end of the dict.
+// It implements io.ReaderFrom.
+func (t *Dict[V]) ReadFrom(r io.Reader) (n int64, err error) {
+	codec, err := t.valueCodec()
+	if err != nil {
+		return
+	}
+	br, ok := r.(byteReader)
+	if ! ok {
+		br = bufio.NewReader(r)
+	}
+	hr := &hashReader{r: br}
+
+	defer func() {
+		n = hr.n
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("%w: unexpected end of data", ErrBadFormat)
+		}
+	}()
+
+	head := make([]byte, len(binaryMagic) + 1)
+	if _, err = io.ReadFull(hr, head); err != nil {
+		return
+	}
+	if string(head[:len(binaryMagic)]) != binaryMagic {
+		return n, fmt.Errorf("%w: bad magic %q", ErrBadFormat, head[:len(binaryMagic)])
+	}
+	if v := head[len(binaryMagic)]; v != binaryVersion {
+		return n, fmt.Errorf("%w: unsupported version %d", ErrBadFormat, v)
+	}
+	count, err := binary.ReadUvarint(hr)
+	if err != nil {
+		return
+	}
+
+	var b builder[V]
+	var prev, val, slab []byte
+	for i := uint64(0); i < count; i++ {
+		var shared, suffix, vlen uint64
+		if shared, err = binary.ReadUvarint(hr); err != nil {
+			return
+		}
+		if suffix, err = binary.ReadUvarint(hr); err != nil {
+			return
+		}
+		if shared > uint64(len(prev)) || suffix > 1<<30 {
+			return n, fmt.Errorf("%w: bad key lengths at item %d", ErrBadFormat, i)
+		}
+		// carve keys out of larger blocks to save allocations
+		klen := int(shared + suffix)
+		if klen > len(slab) {
+			slab = make([]byte, max(klen, 64 << 10))
+		}
+		key := slab[:klen:klen]
+		slab = slab[klen:]
+		copy(key, prev[:shared])
+		if _, err = io.ReadFull(hr, key[shared:]); err != nil {
+			return
+		}
+		if vlen, err = binary.ReadUvarint(hr); err != nil {
+			return
+		}
+		if vlen > 1<<30 {
+			return n, fmt.Errorf("%w: bad value length at item %d", ErrBadFormat, i)
+		}
+		if uint64(cap(val)) < vlen {
+			val = make([]byte, vlen)
+		}
+		val = val[:vlen]
+		if _, err = io.ReadFull(hr, val); err != nil {
+			return
+		}
+		v, verr := codec.DecodeValue(val)
+		if verr != nil {
+			return n, fmt.Errorf("dict: item %d: %w", i, verr)
+		}
+		if err = b.add(key, v); err != nil {
+			return n, fmt.Errorf("%w: %w", ErrBadFormat, err)
+		}
+		prev = key
+	}
+	sum := hr.crc
+	var tail [4]byte
+	if _, err = io.ReadFull(hr, tail[:]); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint32(tail[:]) != sum {
+		return n, ErrBadChecksum
+	}
+	b.finish(t)
+	return
+}
+
+
+// countWriter counts bytes written to the underlying writer
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.w.Write(p)
+	cw.n += int64(n)
+	return
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// hashReader counts and checksums bytes read from the underlying reader
+type hashReader struct {
+	r   byteReader
+	n   int64
+	crc uint32
+}
+
+func (hr *hashReader) Read(p []byte) (n int, err error) {
+	n, err = hr.r.Read(p)
+	hr.n  += int64(n)
+	hr.crc = crc32.Update(hr.crc, crcTable, p[:n])
+	return
+}
+
+func (hr *hashReader) ReadByte() (b byte, err error) {
+	if b, err = hr.r.ReadByte(); err == nil {
+		hr.n++
+		hr.crc = crc32.Update(hr.crc, crcTable, []byte{b})
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package dict
+
+import "testing"
+import "bytes"
+import "errors"
+import "fmt"
+import "strings"
+import "analyzers/lib/critbit/arena"
+
+func Test_BinaryRoundTrip(t *testing.T) {
+	tr := NewDict[int]().SetCodec(IntCodec{})
+	for i := 0; i < 1000; i++ {
+		tr.Set([]byte(fmt.Sprintf("key/%d", i*7)), i - 500)
+	}
+	data, err := tr.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if n, err := tr.WriteTo(&buf); err != nil || n != int64(len(data)) || ! bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("WriteTo -> (%d, %v), does not match MarshalBinary", n, err)
+	}
+
+	res := NewDict[int]().SetCodec(IntCodec{})
+	res.Set([]byte("stale"), 1)
+	if err := res.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if res.Len() != tr.Len() {
+		t.Errorf("wrong length: expected %d, got %d", tr.Len(), res.Len())
+	}
+	checkSizes(t, &res.root)
+	exp, got := tr.Items(), res.Items()
+	for i := range exp {
+		if ! bytes.Equal(exp[i].Key, got[i].Key) || exp[i].Val != got[i].Val {
+			t.Errorf("item %d: expected %q=%d, got %q=%d", i, exp[i].Key, exp[i].Val, got[i].Key, got[i].Val)
+			break
+		}
+	}
+	if item, ok := res.Select(10); ! ok || ! bytes.Equal(item.Key, exp[10].Key) {
+		t.Errorf("Select(10) -> %q, expected %q", item.Key, exp[10].Key)
+	}
+
+	// ReadFrom stops at the end of the dict
+	buf.WriteString("tail")
+	if n, err := res.ReadFrom(&buf); err != nil || n != int64(len(data)) || buf.String() != "tail" {
+		t.Errorf("ReadFrom -> (%d, %v), %q left", n, err, buf.String())
+	}
+}
+
+func Test_BinaryDefaultCodecs(t *testing.T) {
+	strs := NewDict[string]()
+	strs.Set([]byte("a"), "x")
+	strs.Set([]byte("ab"), "")
+	data, err := strs.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	res := NewDict[string]()
+	if err := res.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if v, _ := res.Get([]byte("a")); v != "x" || res.Len() != 2 {
+		t.Errorf("wrong content: %q", res.Keys())
+	}
+
+	empty := NewDict[[]byte]()
+	if data, err = empty.MarshalBinary(); err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	if err := NewDict[[]byte]().UnmarshalBinary(data); err != nil {
+		t.Errorf("UnmarshalBinary of an empty dict failed: %v", err)
+	}
+
+	ints := NewDict[int]()
+	ints.Set([]byte("a"), -300)
+	if data, err = ints.MarshalBinary(); err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	ints = NewDict[int]()
+	if err := ints.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if v, _ := ints.Get([]byte("a")); v != -300 || ints.Len() != 1 {
+		t.Errorf("wrong content: %q", ints.Keys())
+	}
+
+	if _, err := NewDict[float64]().MarshalBinary(); ! errors.Is(err, ErrNoCodec) {
+		t.Errorf("expected ErrNoCodec, got %v", err)
+	}
+}
+
+func Test_BinaryCorrupted(t *testing.T) {
+	tr := NewDict[string]()
+	for _, s := range []string{"aa", "aaa", "aab", "ab", "ba"} {
+		tr.Set([]byte(s), s)
+	}
+	data, _ := tr.MarshalBinary()
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"magic",     append([]byte("XBDT"), data[4:]...), ErrBadFormat},
+		{"version",   append(append([]byte("CBDT"), 9), data[5:]...), ErrBadFormat},
+		{"truncated", data[:len(data)-1], ErrBadFormat},
+		{"trailing",  append(bytes.Clone(data), 0), ErrBadFormat},
+		{"checksum",  append(bytes.Clone(data[:len(data)-1]), data[len(data)-1]^1), ErrBadChecksum},
+		{"value",     bytes.Replace(data, []byte("aab"), []byte("aax"), 1), ErrBadChecksum},
+	}
+	for _, test := range tests {
+		res := NewDict[string]()
+		res.Set([]byte("keep"), "")
+		if err := res.UnmarshalBinary(test.data); ! errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+		if test.name != "trailing" && (res.Len() != 1 || res.Keys()[0][0] != 'k') {
+			t.Errorf("%s: the dict must stay intact, got %q", test.name, res.Keys())
+		}
+	}
+
+	// the blocks of the old keys are dropped, the mode is kept
+	res := NewDict[string]().SetKeyMode(arena.Copy)
+	res.Set([]byte("old"), "")
+	if err := res.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if res.arena.Size() != 0 || res.arena.Mode() != arena.Copy {
+		t.Errorf("the key arena must be reset keeping its mode (size %d)", res.arena.Size())
+	}
+
+	// a duplicate key is reported by the builder
+	dup := []byte("CBDT\x01\x02\x00\x01a\x00\x01\x00\x00\x00\x00\x00\x00")
+	if err := NewDict[string]().UnmarshalBinary(dup); ! errors.Is(err, ErrBadFormat) || strings.Count(err.Error(), "dict:") != 1 {
+		t.Errorf("expected ErrBadFormat with a single prefix, got %v", err)
+	}
+}
+
+func Test_BuilderOrder(t *testing.T) {
+	var b builder[int]
+	if err := b.add([]byte("b"), 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", ""} {
+		if err := b.add([]byte(key), 0); err == nil {
+			t.Errorf("adding %q after \"b\" must fail", key)
+		}
+	}
+}
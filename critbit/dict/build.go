@@ -0,0 +1,105 @@
+package dict
+
+import "fmt"
+import "iter"
+
+
+// BuildSorted builds a dict of items given in increasing key order in linear
+// time. It fails on out-of-order and duplicate keys.
+func BuildSorted[V any](items []Item[V]) (*Dict[V], error) {
+	var b builder[V]
+	for _, item := range items {
+		if err := b.add(item.Key, item.Val); err != nil {
+			return nil, fmt.Errorf("dict: %w", err)
+		}
+	}
+	t := &Dict[V]{}
+	b.finish(t)
+	return t, nil
+}
+
+// BuildSortedSeq is like BuildSorted but takes items from an iterator.
+func BuildSortedSeq[V any](seq iter.Seq2[[]byte, V]) (*Dict[V], error) {
+	var b builder[V]
+	for key, val := range seq {
+		if err := b.add(key, val); err != nil {
+			return nil, fmt.Errorf("dict: %w", err)
+		}
+	}
+	t := &Dict[V]{}
+	b.finish(t)
+	return t, nil
+}
+
+
+// builder assembles a tree bottom-up from keys coming in increasing order.
+// It keeps the right spine of the tree built so far, so that every key is
+// linked in amortized O(1) time. Subtree sizes are computed by finish().
+type builder[V any] struct {
+	root  Ref[V]
+	// spine holds the Refs on the right edge of the tree (from the root down)
+	spine []*Ref[V]
+	size  int
+}
+
+// add appends an item whose key must be greater than all keys added before.
+// The errors have no package prefix, the callers wrap them.
+func (b *builder[V]) add(key []byte, val V) error {
+	if len(key) == 0 {
+		return fmt.Errorf("empty key at position %d", b.size)
+	}
+	if b.size == 0 {
+		b.root.Item = Item[V]{key, val}
+		b.spine = append(b.spine[:0], &b.root)
+		b.size++
+		return nil
+	}
+	last := b.spine[len(b.spine)-1]
+	off, bit, ldir, differ := critbit(key, last.Key)
+	if ! differ {
+		return fmt.Errorf("duplicate key %q at position %d", key, b.size)
+	}
+	if ldir == 1 {
+		return fmt.Errorf("key %q at position %d is less than the previous key %q", key, b.size, last.Key)
+	}
+	// ascend the spine while the nodes are below the new crit bit
+	i := len(b.spine) - 1
+	for i > 0 {
+		n := b.spine[i-1].node
+		if n.off < off || n.off == off && n.bit > bit {
+			break
+		}
+		i--
+	}
+	// insert new node (the new key always goes right)
+	wp := b.spine[i]
+	nn := &Node[V]{off:off, bit:bit}
+	nn.child[0] = *wp
+	nn.child[1].Item = Item[V]{key, val}
+	*wp = Ref[V]{node: nn}
+
+	b.spine = append(b.spine[:i+1], &nn.child[1])
+	b.size++
+	return nil
+}
+
+// finish computes subtree sizes and moves the tree into a Dict replacing its
+// content. The key arena starts afresh in the same mode.
+func (b *builder[V]) finish(t *Dict[V]) {
+	sumSizes(&b.root)
+	t.root  = b.root
+	t.size  = b.size
+	t.arena = t.arena.Fork()
+	t.gen++
+	*b = builder[V]{}
+}
+
+// sumSizes sets subtree sizes of all nodes under the Ref and returns the count
+func sumSizes[V any](ref *Ref[V]) int {
+	if ref.node == nil {
+		return 1
+	}
+	n := ref.node
+	n.size = sumSizes(&n.child[0]) + sumSizes(&n.child[1])
+	return n.size
+}
@@ -0,0 +1,38 @@
+package dict
+
+import "testing"
+import "strings"
+
+func Test_BuildSorted(t *testing.T) {
+	var items []Item[int]
+	for i, s := range []string{"aa", "aaa", "aab", "ab", "b", "ba", "bb", "bba", "bbb", "c"} {
+		items = append(items, Item[int]{[]byte(s), i})
+	}
+	tr, err := BuildSorted(items)
+	if err != nil {
+		t.Fatalf("BuildSorted failed: %v", err)
+	}
+	if ! testItemsEq(tr.Items(), items) || tr.Len() != len(items) {
+		t.Errorf("Got: %v", tr.Items())
+	}
+	checkSizes(t, &tr.root)
+	for i, item := range items {
+		if r := tr.Rank(item.Key); r != i {
+			t.Errorf("Rank(%q) -> %d, expected %d", item.Key, r, i)
+		}
+	}
+	seq, err := BuildSortedSeq(tr.All())
+	if err != nil || ! testItemsEq(seq.Items(), items) {
+		t.Errorf("BuildSortedSeq -> %v, %v", seq.Items(), err)
+	}
+	checkSizes(t, &seq.root)
+
+	bad := []Item[int]{{[]byte("a"), 1}, {[]byte("c"), 2}, {[]byte("b"), 3}}
+	if _, err := BuildSorted(bad); err == nil || ! strings.Contains(err.Error(), "less than the previous key") {
+		t.Errorf("expected an out-of-order error, got %v", err)
+	}
+	bad = []Item[int]{{[]byte("a"), 1}, {[]byte("a"), 2}}
+	if _, err := BuildSorted(bad); err == nil || ! strings.HasPrefix(err.Error(), "dict: duplicate key") {
+		t.Errorf("expected a duplicate key error, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package dict
+
+import "io"
+import "sync"
+import "sync/atomic"
+
+
+// ConcurrentDict is a Dict safe for concurrent use. Readers never lock:
+// they work with an atomically published immutable version (PersistentDict).
+// Writers are serialized and publish copy-on-write updates.
+type ConcurrentDict[V any] struct {
+	// mu serializes writers
+	mu  sync.Mutex
+	cur atomic.Pointer[PersistentDict[V]]
+}
+
+func NewConcurrentDict[V any](items ...Item[V]) *ConcurrentDict[V] {
+	cd := &ConcurrentDict[V]{}
+	cd.cur.Store(NewPersistentDict(items...))
+	return cd
+}
+
+// Snapshot returns the current version. It never changes afterwards.
+func (cd *ConcurrentDict[V]) Snapshot() *PersistentDict[V] {
+	if pd := cd.cur.Load(); pd != nil {
+		return pd
+	}
+	return &PersistentDict[V]{}
+}
+
+// Len returns the number of keys in the tree.
+func (cd *ConcurrentDict[V]) Len() int {
+	return cd.Snapshot().Len()
+}
+
+// Get returns a value associated with the key
+func (cd *ConcurrentDict[V]) Get(key []byte) (V, bool) {
+	return cd.Snapshot().Get(key)
+}
+
+// Iter calls a handler for all keys with a given prefix (see Dict.Iter).
+// It walks a snapshot, so concurrent updates are not visible to the handler.
+func (cd *ConcurrentDict[V]) Iter(prefix []byte, handler func(Item[V]) bool) bool {
+	return cd.Snapshot().Iter(prefix, handler)
+}
+
+// IterRange calls a handler for all keys between lo and hi (see Dict.IterRange).
+func (cd *ConcurrentDict[V]) IterRange(lo, hi []byte, opts RangeOpts, handler func(Item[V]) bool) bool {
+	return cd.Snapshot().IterRange(lo, hi, opts, handler)
+}
+
+// Stats collects statistics of the current version (see Dict.Stats)
+func (cd *ConcurrentDict[V]) Stats() Stats {
+	return cd.Snapshot().Stats()
+}
+
+// Dump writes a text rendering of the current version (see Dict.Dump)
+func (cd *ConcurrentDict[V]) Dump(w io.Writer, opts DumpOpts) error {
+	return cd.Snapshot().Dump(w, opts)
+}
+
+// WriteDOT writes a Graphviz rendering of the current version (see Dict.WriteDOT)
+func (cd *ConcurrentDict[V]) WriteDOT(w io.Writer) error {
+	return cd.Snapshot().WriteDOT(w)
+}
+
+// FindPathGE returns a path to a Ref that is greater-or-equal to the key
+func (cd *ConcurrentDict[V]) FindPathGE(key []byte) *RefPath[V] {
+	return cd.Snapshot().t.FindPathGE(key)
+}
+
+// FindPathLE returns a path to a Ref that is less-or-equal to the key
+func (cd *ConcurrentDict[V]) FindPathLE(key []byte) *RefPath[V] {
+	return cd.Snapshot().t.FindPathLE(key)
+}
+
+// Replace applies a func to a previous value of a key and replaces it with
+// the result (see Dict.Replace). The func is called under the writer lock.
+func (cd *ConcurrentDict[V]) Replace(key []byte, replace func(V, bool) V) (prev V, ok bool) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	next := cd.Snapshot().Replace(key, func(old V, exists bool) V {
+		prev, ok = old, exists
+		return replace(old, exists)
+	})
+	cd.cur.Store(next)
+	return
+}
+
+// Set associates a given value with a key. Returns previous value (if any).
+func (cd *ConcurrentDict[V]) Set(key []byte, val V) (prev V, ok bool) {
+	return cd.Replace(key, func(V, bool) V {return val})
+}
+
+// Del removes the key from the tree and returns its value (if any)
+func (cd *ConcurrentDict[V]) Del(key []byte) (val V, ok bool) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	pd := cd.Snapshot()
+	if val, ok = pd.Get(key); ok {
+		cd.cur.Store(pd.Del(key))
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package dict
+
+import "testing"
+import "sync"
+import "bytes"
+import "encoding/binary"
+
+func Test_ConcurrentBasic(t *testing.T) {
+	var cd ConcurrentDict[int]  // the zero value is ready to use
+
+	if _, ok := cd.Get([]byte("a")); ok || cd.Len() != 0 {
+		t.Error("must be empty")
+	}
+	cd.Set([]byte("a"), 1)
+	snap := cd.Snapshot()
+	if prev, ok := cd.Set([]byte("a"), 2); prev != 1 || ! ok {
+		t.Errorf("wrong .Set() result: (%v, %v)", prev, ok)
+	}
+	if v, ok := cd.Del([]byte("a")); v != 2 || ! ok {
+		t.Errorf("wrong .Del() result: (%v, %v)", v, ok)
+	}
+	if v, _ := snap.Get([]byte("a")); v != 1 || cd.Len() != 0 {
+		t.Errorf("the snapshot must keep the old value, got %v", v)
+	}
+}
+
+// Test_ConcurrentStress mixes lock-free readers with writers (run with -race).
+func Test_ConcurrentStress(t *testing.T) {
+	const writers, readers, ops = 4, 8, 500
+
+	cd := NewConcurrentDict[uint32]()
+	key := func(w, i int) []byte {
+		k := make([]byte, 6)
+		k[0], k[1] = 'w', byte(w)
+		binary.BigEndian.PutUint32(k[2:], uint32(i))
+		return k
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				cd.Set(key(w, i), uint32(i))
+				if i % 3 == 0 {
+					cd.Del(key(w, i/2))
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				if v, ok := cd.Get(key(r % writers, i)); ok && v != uint32(i) {
+					t.Errorf("wrong value %v of key %d/%d", v, r % writers, i)
+				}
+				// every snapshot must be sorted and consistent
+				var prev []byte
+				n := 0
+				cd.Iter([]byte{'w', byte(r % writers)}, func(item Item[uint32]) bool {
+					if prev != nil && bytes.Compare(prev, item.Key) >= 0 {
+						t.Errorf("unordered keys %q, %q", prev, item.Key)
+					}
+					prev = item.Key
+					n++
+					return n < 50
+				})
+				if path := cd.FindPathGE(key(r % writers, i)); path.GetLeaf() != nil {
+					_ = path.TrackNext()
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	snap := cd.Snapshot()
+	if n := len(snap.Keys()); n != cd.Len() {
+		t.Errorf("wrong length %d, expected %d", cd.Len(), n)
+	}
+	checkSizes(t, &snap.t.root)
+}
@@ -0,0 +1,156 @@
+package dict
+
+import "errors"
+
+
+// ErrCursorStale is reported by a Cursor whose Dict was modified under it
+var ErrCursorStale = errors.New("dict: cursor invalidated by a modification")
+
+// Cursor is a position in a Dict used for ordered navigation.
+// Any Set/Del that changes the tree shape invalidates the cursor: it then
+// reports ErrCursorStale instead of walking stale nodes. Replacing a value
+// of an existing key keeps the cursor valid.
+type Cursor[V any] struct {
+	dict *Dict[V]
+	path *RefPath[V]
+	gen  uint64
+	err  error
+}
+
+// Cursor returns a new unpositioned cursor
+func (t *Dict[V]) Cursor() *Cursor[V] {
+	return &Cursor[V]{dict: t}
+}
+
+// reset forgets the current position and error
+func (c *Cursor[V]) reset() {
+	c.path = nil
+	c.gen  = c.dict.gen
+	c.err  = nil
+}
+
+// stale checks the modification generation and invalidates the cursor
+func (c *Cursor[V]) stale() bool {
+	if c.gen != c.dict.gen {
+		c.path = nil
+		c.err  = ErrCursorStale
+		return true
+	}
+	return false
+}
+
+// settle keeps the path if it points to a leaf or forgets it otherwise
+func (c *Cursor[V]) settle(path *RefPath[V]) bool {
+	if path.GetLeaf() == nil {
+		c.path = nil
+		return false
+	}
+	c.path = path
+	return true
+}
+
+// First positions the cursor at the smallest key
+func (c *Cursor[V]) First() bool {
+	c.reset()
+	return c.settle(c.dict.FindPathEdge(0))
+}
+
+// Last positions the cursor at the greatest key
+func (c *Cursor[V]) Last() bool {
+	c.reset()
+	return c.settle(c.dict.FindPathEdge(1))
+}
+
+// Seek positions the cursor at the key. It returns false (and leaves the
+// cursor invalid) when the key is missing.
+func (c *Cursor[V]) Seek(key []byte) bool {
+	c.reset()
+	if c.dict.Empty() {
+		return false
+	}
+	// walk for best member
+	path := NewRefPath[V]()
+	ref  := &c.dict.root
+	path.Append(ref, 1)
+
+	for ref.node != nil {
+		dir := ref.node.dir(key)
+		ref = &ref.node.child[dir]
+		path.Append(ref, dir)
+	}
+	// check for membership
+	if len(key) != len(ref.Key) {
+		return false
+	}
+	for i, b := range ref.Key {
+		if b != key[i] {
+			return false
+		}
+	}
+	c.path = path
+	return true
+}
+
+// SeekGE positions the cursor at the smallest key greater-or-equal to the key
+func (c *Cursor[V]) SeekGE(key []byte) bool {
+	c.reset()
+	return c.settle(c.dict.FindPathGE(key))
+}
+
+// SeekLE positions the cursor at the greatest key less-or-equal to the key
+func (c *Cursor[V]) SeekLE(key []byte) bool {
+	c.reset()
+	return c.settle(c.dict.FindPathLE(key))
+}
+
+// Next advances the cursor to the next key in order
+func (c *Cursor[V]) Next() bool {
+	if c.path == nil || c.stale() {
+		return false
+	}
+	if c.path.TrackNext() == nil {
+		c.path = nil
+		return false
+	}
+	return true
+}
+
+// Prev moves the cursor to the previous key in order
+func (c *Cursor[V]) Prev() bool {
+	if c.path == nil || c.stale() {
+		return false
+	}
+	if c.path.TrackPrev() == nil {
+		c.path = nil
+		return false
+	}
+	return true
+}
+
+// Valid reports whether the cursor points to an existing key
+func (c *Cursor[V]) Valid() bool {
+	return c.path != nil && !c.stale()
+}
+
+// Err returns ErrCursorStale if the cursor was invalidated by a modification
+func (c *Cursor[V]) Err() error {
+	return c.err
+}
+
+// Item returns the current item (zero Item if the cursor is not valid)
+func (c *Cursor[V]) Item() (item Item[V]) {
+	if c.Valid() {
+		item = c.path.GetLeaf().Item
+	}
+	return
+}
+
+// Key returns the current key (nil if the cursor is not valid)
+func (c *Cursor[V]) Key() []byte {
+	return c.Item().Key
+}
+
+// Value returns the current value (zero value if the cursor is not valid)
+func (c *Cursor[V]) Value() V {
+	return c.Item().Val
+}
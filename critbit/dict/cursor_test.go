@@ -0,0 +1,106 @@
+package dict
+
+import "testing"
+import "bytes"
+
+func Test_CursorEmpty(t *testing.T) {
+	c := NewDict[int]().Cursor()
+	if c.First() || c.Last() || c.Seek([]byte("a")) || c.SeekGE(nil) || c.SeekLE([]byte("z")) {
+		t.Error("positioning on an empty dict must fail")
+	}
+	if c.Valid() || c.Next() || c.Prev() || c.Key() != nil {
+		t.Error("cursor must be invalid")
+	}
+}
+
+func Test_CursorWalk(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	expected := tr.Keys()
+	c := tr.Cursor()
+
+	var got [][]byte
+	for ok := c.First(); ok; ok = c.Next() {
+		got = append(got, c.Key())
+	}
+	if ! testKeysEq(got, expected) {
+		t.Errorf("forward walk: got %q", got)
+	}
+
+	got = got[:0]
+	for ok := c.Last(); ok; ok = c.Prev() {
+		got = append([][]byte{c.Key()}, got...)
+	}
+	if ! testKeysEq(got, expected) {
+		t.Errorf("backward walk: got %q", got)
+	}
+	if c.Err() != nil {
+		t.Errorf("unexpected error: %v", c.Err())
+	}
+}
+
+func Test_CursorSeek(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	tests := []struct {
+		key    string
+		seek   string
+		ge, le string
+	}{
+		{"",     "",    "aa",  ""},
+		{"a",    "",    "aa",  ""},
+		{"aa",   "aa",  "aa",  "aa"},
+		{"aab",  "aab", "aab", "aab"},
+		{"aac",  "",    "ab",  "aab"},
+		{"b",    "",    "ba",  "ab"},
+		{"bbc",  "",    "",    "bbb"},
+		{"c",    "",    "",    "bbb"},
+	}
+	c := tr.Cursor()
+	for i, test := range tests {
+		key := []byte(test.key)
+		if c.Seek(key); string(c.Key()) != test.seek {
+			t.Errorf("test %d: Seek(%q) -> %q, expected %q", i, key, c.Key(), test.seek)
+		}
+		if c.SeekGE(key); string(c.Key()) != test.ge {
+			t.Errorf("test %d: SeekGE(%q) -> %q, expected %q", i, key, c.Key(), test.ge)
+		}
+		if c.SeekLE(key); string(c.Key()) != test.le {
+			t.Errorf("test %d: SeekLE(%q) -> %q, expected %q", i, key, c.Key(), test.le)
+		}
+	}
+	if ! c.Seek([]byte("ba")) || c.Value() != 4 {
+		t.Errorf("wrong value at %q: %v", c.Key(), c.Value())
+	}
+	if ! c.Prev() || ! bytes.Equal(c.Key(), []byte("ab")) {
+		t.Errorf("wrong key after Prev(): %q", c.Key())
+	}
+}
+
+func Test_CursorStale(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	c  := tr.Cursor()
+	c.Seek([]byte("ab"))
+
+	// replacing a value keeps the cursor valid
+	tr.Set([]byte("ab"), 100)
+	if ! c.Valid() || c.Value() != 100 {
+		t.Errorf("cursor must stay valid after a value update: %v", c.Err())
+	}
+
+	// structural modifications invalidate it
+	tr.Del([]byte("ba"))
+	if c.Next() {
+		t.Errorf("Next() must fail on a stale cursor, got %q", c.Key())
+	}
+	if c.Valid() || c.Err() != ErrCursorStale {
+		t.Errorf("expected ErrCursorStale, got %v", c.Err())
+	}
+
+	// repositioning recovers it
+	if ! c.First() || c.Err() != nil {
+		t.Errorf("First() must reset the cursor: %v", c.Err())
+	}
+	tr.Set([]byte("zz"), 0)
+	if c.Valid() || c.Err() != ErrCursorStale {
+		t.Errorf("expected ErrCursorStale after insertion, got %v", c.Err())
+	}
+}
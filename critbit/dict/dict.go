@@ -1,22 +1,40 @@
 package dict
 
 import "fmt"
+import "bytes"
+import "analyzers/lib/critbit/arena"
 
 
-type Item struct {
+type Item[V any] struct {
 	Key []byte
-	Val interface{}
+	Val V
 }
-type ItemSlice []Item
+type ItemSlice[V any] []Item[V]
 
 
-// Ref holds either a Item or a Node index
-type Ref struct {
-	Item
-	node *Node
+// Ref holds either an Item (stored inline) or a Node pointer
+type Ref[V any] struct {
+	Item[V]
+	node *Node[V]
 }
 
-func (ref *Ref) String() string {
+// count returns the number of leaves under the Ref
+func (ref *Ref[V]) count() int {
+	if ref.node != nil {
+		return ref.node.size
+	}
+	return 1
+}
+
+// first returns the leftmost key under the Ref
+func (ref *Ref[V]) first() []byte {
+	for ref.node != nil {
+		ref = &ref.node.child[0]
+	}
+	return ref.Key
+}
+
+func (ref *Ref[V]) String() string {
 	if ref == nil {
 		return "Ref(nil)"
 	}
@@ -28,8 +46,10 @@ func (ref *Ref) String() string {
 }
 
 
-type Node struct {
-	child [2]Ref
+type Node[V any] struct {
+	child [2]Ref[V]
+	// size is the number of leaves in the subtree
+	size  int
 	// off is the offset of the differing byte
 	off   int
 	// bit contains the single crit bit in the differing byte
@@ -37,13 +57,30 @@ type Node struct {
 }
 
 
-type Dict struct {
+// RangeOpts configures bounds and direction of IterRange
+type RangeOpts struct {
+	// LoExclusive excludes a key equal to the lower bound
+	LoExclusive bool
+	// HiExclusive excludes a key equal to the upper bound
+	HiExclusive bool
+	// Reverse iterates from the upper bound down to the lower one
+	Reverse     bool
+}
+
+
+type Dict[V any] struct {
 	size int
-	root Ref
+	root Ref[V]
+	// gen is bumped by every structural modification (used by cursors)
+	gen  uint64
+	// codec is used by the binary serialization
+	codec ValueCodec[V]
+	// arena stores the keys (the zero value stores them as passed)
+	arena arena.Keys
 }
 
 // dir calculates the direction for the given key
-func (n *Node) dir(key []byte) byte {
+func (n *Node[V]) dir(key []byte) byte {
 	if n.off < len(key) && key[n.off] & n.bit != 0 {
 		//fmt.Printf("dir() -> 1   off=%v  byte=%08b  bit=%08b\n", n.off, key[n.off], n.bit)
 		return 1
@@ -52,29 +89,29 @@ func (n *Node) dir(key []byte) byte {
 	return 0
 }
 
-func InitDict(dict *Dict, items ...Item) *Dict {
-	*dict = Dict{}
+func InitDict[V any](dict *Dict[V], items ...Item[V]) *Dict[V] {
+	*dict = Dict[V]{gen: dict.gen + 1, codec: dict.codec, arena: dict.arena}
 	for _, item := range items {
 		dict.Set(item.Key, item.Val)
 	}
 	return dict
 }
 
-func NewDict(items ...Item) *Dict {
-	return InitDict(&Dict{}, items...)
+func NewDict[V any](items ...Item[V]) *Dict[V] {
+	return InitDict(&Dict[V]{}, items...)
 }
 
 // Len returns the number of keys in the tree.
-func (t *Dict) Len() int {
+func (t *Dict[V]) Len() int {
 	return t.size
 }
 
-func (t *Dict) Empty() bool {
+func (t *Dict[V]) Empty() bool {
 	return t.root.node == nil && len(t.root.Key) == 0
 }
 
 // Get returns a value associated with the key
-func (t *Dict) Get(key []byte) (val interface{}, ok bool) {
+func (t *Dict[V]) Get(key []byte) (val V, ok bool) {
 	// test for empty tree
 	if t.Empty() {
 		return
@@ -102,14 +139,23 @@ func (t *Dict) Get(key []byte) (val interface{}, ok bool) {
 }
 
 // Replace applies a func to a previous value of a key and replaces it with the result.
-// Returns the previous value.
-func (t *Dict) Replace(key []byte, replace func(interface{}) interface{}) interface{} {
+// The func is told whether the key exists (a zero value is passed otherwise).
+// Returns the previous value and whether the key existed.
+func (t *Dict[V]) Replace(key []byte, replace func(V, bool) V) (prev V, ok bool) {
+	return t.replace(key, replace, false)
+}
+
+// replace is Replace storing a copy of a new key if necessary
+func (t *Dict[V]) replace(key []byte, replace func(V, bool) V, copy bool) (prev V, ok bool) {
+	var zero V
+
 	// test for empty tree
 	if t.Empty() {
-		t.root.Key = key
-		t.root.Val = replace(nil)
+		t.root.Key = t.arena.Store(key, copy)
+		t.root.Val = replace(zero, false)
 		t.size++
-		return nil
+		t.gen++
+		return
 	}
 	// walk for best member
 	p := &t.root
@@ -120,7 +166,6 @@ func (t *Dict) Replace(key []byte, replace func(interface{}) interface{}) interf
 	// find critical bit
 	var off int
 	var ch, bit byte
-	var prev interface{}
 	var klen = len(key)
 	var plen = len(p.Key)
 
@@ -139,10 +184,10 @@ func (t *Dict) Replace(key []byte, replace func(interface{}) interface{}) interf
 		bit = ch
 		goto ByteFound
 	}
-	// key exists - just increment its dict
+	// key exists - just replace its value
 	prev  = p.Val
-	p.Val = replace(prev)
-	return prev
+	p.Val = replace(prev, true)
+	return prev, true
 ByteFound:
 	// find differing bit
 	bit |= bit >> 1
@@ -154,8 +199,8 @@ ByteFound:
 		ndir++
 	}
 	// insert new node
-	nn := Node{off:off, bit:bit}
-	nn.child[1-ndir].Item = Item{key, replace(nil)}
+	nn := Node[V]{off:off, bit:bit}
+	nn.child[1-ndir].Item = Item[V]{t.arena.Store(key, copy), replace(zero, false)}
 
 	// walk for best insertion node
 	wp := &t.root
@@ -164,31 +209,34 @@ ByteFound:
 		if n.off > off || n.off == off && n.bit < bit {
 			break
 		}
+		n.size++
 		// try next node
 		wp = &n.child[n.dir(key)]
 	}
 	nn.child[ndir] = *wp
+	nn.size = wp.count() + 1
 	wp.node = &nn
-	wp.Key  = nil
+	wp.Item = Item[V]{}
 	t.size++
+	t.gen++
 
-	return nil
+	return
 }
 
 // Set associates a given value with a key. Returns previous value (if any).
-func (t *Dict) Set(key []byte, val interface{}) interface{} {
-	return t.Replace(key, func(interface{}) interface{} {return val})
+func (t *Dict[V]) Set(key []byte, val V) (prev V, ok bool) {
+	return t.Replace(key, func(V, bool) V {return val})
 }
 
 // Del removes the key from the tree and returns its value (if any)
-func (t *Dict) Del(key []byte) (val interface{}) {
+func (t *Dict[V]) Del(key []byte) (val V, ok bool) {
 	// test for empty tree
 	if t.Empty() {
 		return
 	}
 	// walk for best member
 	var dir byte
-	var wp  *Ref
+	var wp  *Ref[V]
 	p := &t.root
 	for p.node != nil {
 		wp = p
@@ -207,22 +255,49 @@ func (t *Dict) Del(key []byte) (val interface{}) {
 		}
 	}
 	val = p.Val
+	ok  = true
 	// delete from the tree
 	t.size--
+	t.gen++
+	t.arena.Release(p.Key)
 	if wp == nil {
-		val = t.root.Val
-		t.root = Ref{}
+		t.root = Ref[V]{}
 		return
 	}
+	t.resize(key, wp, -1)
 	*wp = wp.node.child[1-dir]
 	return
 }
 
+// DeletePrefix removes all keys with a given prefix and returns their number.
+// The whole subtree is unlinked at once and the number comes from its size,
+// so it takes O(depth) time (plus O(k) for k removed keys in the
+// arena.Debug mode).
+func (t *Dict[V]) DeletePrefix(prefix []byte) int {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return 0
+	}
+	n := top.count()
+	t.size -= n
+	t.gen++
+	if t.arena.Mode() == arena.Debug {
+		// only the debug mode keeps track of the stored keys
+		t.iterate(*top, 0, func(item Item[V]) bool {
+			t.arena.Release(item.Key)
+			return true
+		})
+	}
+	t.resize(prefix, parent, -n)
+	t.unlink(top, parent)
+	return n
+}
+
 // Merge merges another Dict into this one. Dicts of common keys are added up.
 // Returns itself.
-func (t *Dict) Merge(other *Dict, prefix []byte) *Dict {
+func (t *Dict[V]) Merge(other *Dict[V], prefix []byte) *Dict[V] {
 	if other != nil {
-		adder := func(item Item) bool {
+		adder := func(item Item[V]) bool {
 			t.Set(item.Key, item.Val)
 			return true
 		}
@@ -233,13 +308,13 @@ func (t *Dict) Merge(other *Dict, prefix []byte) *Dict {
 
 
 // FindPathGE returns a path to a Ref that is greater-or-equal to the key
-func (t *Dict) FindPathGE(key []byte) (path *RefPath) {
+func (t *Dict[V]) FindPathGE(key []byte) (path *RefPath[V]) {
 	// test empty tree
 	if t.Empty() {
 		return
 	}
 	// descend to the closest leaf
-	path = NewRefPath()
+	path = NewRefPath[V]()
 	ref := &t.root
 	dir := byte(1)
 	path.Append(ref, dir)
@@ -318,13 +393,13 @@ func (t *Dict) FindPathGE(key []byte) (path *RefPath) {
 }
 
 // FindPathLE returns a path to a Ref that is less-or-equal to the key
-func (t *Dict) FindPathLE(key []byte) (path *RefPath) {
+func (t *Dict[V]) FindPathLE(key []byte) (path *RefPath[V]) {
 	// test empty tree
 	if t.Empty() {
 		return
 	}
 	// descend to the closest leaf
-	path = NewRefPath()
+	path = NewRefPath[V]()
 	ref := &t.root
 	dir := byte(1)
 	path.Append(ref, dir)
@@ -402,8 +477,25 @@ func (t *Dict) FindPathLE(key []byte) (path *RefPath) {
 	return
 }
 
+// FindPathEdge returns a path to the leftmost (dir=0) or rightmost (dir=1) Ref
+func (t *Dict[V]) FindPathEdge(dir byte) (path *RefPath[V]) {
+	// test empty tree
+	if t.Empty() {
+		return
+	}
+	path = NewRefPath[V]()
+	ref := &t.root
+	path.Append(ref, 1)
+
+	for ref.node != nil {
+		ref = &ref.node.child[dir]
+		path.Append(ref, dir)
+	}
+	return
+}
+
 // FindPathRange returns a pair of paths to a min/max Refs having a given prefix
-func (t *Dict) FindPathRange(prefix []byte) (min, max *RefPath) {
+func (t *Dict[V]) FindPathRange(prefix []byte) (min, max *RefPath[V]) {
 	// test empty tree
 	if t.Empty() {
 		return
@@ -412,7 +504,7 @@ func (t *Dict) FindPathRange(prefix []byte) (min, max *RefPath) {
 	lpref := len(prefix)
 	ref   := &t.root
 	dir   := byte(1)
-	path  := NewRefPath()
+	path  := NewRefPath[V]()
 	path.Append(ref, dir)
 
 	if lpref > 0 {
@@ -465,49 +557,209 @@ func (t *Dict) FindPathRange(prefix []byte) (min, max *RefPath) {
 }
 
 
-// Iter calls a handler for all keys with a given prefix.
-// It returns whether all prefixed keys were iterated.
-// The handler can continue the process by returning true or abort with false.
-func (t *Dict) Iter(prefix []byte, handler func(Item) bool) bool {
+// Min returns the smallest item with a given prefix.
+func (t *Dict[V]) Min(prefix []byte) (item Item[V], ok bool) {
+	if leaf, _ := t.edge(prefix, 0); leaf != nil {
+		return leaf.Item, true
+	}
+	return
+}
+
+// Max returns the greatest item with a given prefix.
+func (t *Dict[V]) Max(prefix []byte) (item Item[V], ok bool) {
+	if leaf, _ := t.edge(prefix, 1); leaf != nil {
+		return leaf.Item, true
+	}
+	return
+}
+
+// PopMin removes the smallest item with a given prefix and returns it.
+func (t *Dict[V]) PopMin(prefix []byte) (item Item[V], ok bool) {
+	return t.pop(prefix, 0)
+}
+
+// PopMax removes the greatest item with a given prefix and returns it.
+func (t *Dict[V]) PopMax(prefix []byte) (item Item[V], ok bool) {
+	return t.pop(prefix, 1)
+}
+
+// pop removes the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix.
+func (t *Dict[V]) pop(prefix []byte, dir byte) (item Item[V], ok bool) {
+	leaf, parent := t.edge(prefix, dir)
+	if leaf == nil {
+		return
+	}
+	item, ok = leaf.Item, true
+	// delete from the tree
+	t.size--
+	t.gen++
+	t.arena.Release(item.Key)
+	t.resize(item.Key, parent, -1)
+	t.unlink(leaf, parent)
+	return
+}
+
+// edge finds the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix
+// along with the Ref of its parent node.
+func (t *Dict[V]) edge(prefix []byte, dir byte) (leaf, parent *Ref[V]) {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return nil, nil
+	}
+	leaf = top
+	for leaf.node != nil {
+		parent = leaf
+		leaf = &leaf.node.child[dir]
+	}
+	return
+}
+
+// resize adds delta to subtree sizes of the nodes on the path of key
+// from the root down to (but excluding) the stop Ref.
+func (t *Dict[V]) resize(key []byte, stop *Ref[V], delta int) {
+	if stop == nil {
+		return
+	}
+	for p := &t.root; p != stop; p = &p.node.child[p.node.dir(key)] {
+		p.node.size += delta
+	}
+}
+
+// unlink removes the subtree at ref from its parent node (nil for the root).
+func (t *Dict[V]) unlink(ref, parent *Ref[V]) {
+	if parent == nil {
+		t.root = Ref[V]{}
+		return
+	}
+	if &parent.node.child[0] == ref {
+		*parent = parent.node.child[1]
+	} else {
+		*parent = parent.node.child[0]
+	}
+}
+
+// findTop returns the topmost Ref holding all keys with a given prefix
+// (nil if there are no such keys) along with the Ref of its parent node.
+func (t *Dict[V]) findTop(prefix []byte) (top, parent *Ref[V]) {
 	// test empty tree
 	if t.Empty() {
-		return true
-	}
-	// shortcut for empty prefix
-	if len(prefix) == 0 {
-		return t.iterate(t.root, handler)
+		return
 	}
 	// walk for best member
-	p, top := t.root, t.root
+	p := &t.root
+	top = p
 	for p.node != nil {
 		newtop := p.node.off < len(prefix)
+		wp := p
 		// try next node
-		p = p.node.child[p.node.dir(prefix)]
+		p = &p.node.child[p.node.dir(prefix)]
 		if newtop {
-			top = p
+			top, parent = p, wp
 		}
 	}
 	if len(p.Key) < len(prefix) {
-		return true
+		return nil, nil
 	}
 	for i := 0; i < len(prefix); i++ {
 		if p.Key[i] != prefix[i] {
-			return true
+			return nil, nil
 		}
 	}
-	return t.iterate(top, handler)
+	return
+}
+
+// Iter calls a handler for all keys with a given prefix.
+// It returns whether all prefixed keys were iterated.
+// The handler can continue the process by returning true or abort with false.
+func (t *Dict[V]) Iter(prefix []byte, handler func(Item[V]) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 0, handler)
+	}
+	return true
+}
+
+// IterReverse is like Iter but calls the handler in a reverse key order.
+func (t *Dict[V]) IterReverse(prefix []byte, handler func(Item[V]) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 1, handler)
+	}
+	return true
+}
+
+// IterRange calls a handler for all keys between lo and hi in key order
+// (or in reverse order if opts.Reverse is set). A nil bound is unbounded,
+// opts select whether the bounds themselves are included.
+// It returns whether all keys in the range were iterated.
+// The handler can continue the process by returning true or abort with false.
+func (t *Dict[V]) IterRange(lo, hi []byte, opts RangeOpts, handler func(Item[V]) bool) bool {
+	var path *RefPath[V]
+	var ref  *Ref[V]
+
+	if ! opts.Reverse {
+		// start from the lower bound and walk right
+		if lo == nil {
+			path = t.FindPathEdge(0)
+		} else {
+			path = t.FindPathGE(lo)
+		}
+		if ref = path.GetLeaf(); ref != nil && opts.LoExclusive && lo != nil && bytes.Equal(ref.Key, lo) {
+			ref = path.TrackNext()
+		}
+		for ; ref != nil; ref = path.TrackNext() {
+			if hi != nil {
+				if c := bytes.Compare(ref.Key, hi); c > 0 || c == 0 && opts.HiExclusive {
+					break
+				}
+			}
+			if ! handler(ref.Item) {
+				return false
+			}
+		}
+		return true
+	}
+	// start from the upper bound and walk left
+	if hi == nil {
+		path = t.FindPathEdge(1)
+	} else {
+		path = t.FindPathLE(hi)
+	}
+	if ref = path.GetLeaf(); ref != nil && opts.HiExclusive && hi != nil && bytes.Equal(ref.Key, hi) {
+		ref = path.TrackPrev()
+	}
+	for ; ref != nil; ref = path.TrackPrev() {
+		if lo != nil {
+			if c := bytes.Compare(ref.Key, lo); c < 0 || c == 0 && opts.LoExclusive {
+				break
+			}
+		}
+		if ! handler(ref.Item) {
+			return false
+		}
+	}
+	return true
 }
 
 // iterate calls the key handler or traverses both node children unless aborted.
-func (t *Dict) iterate(p Ref, h func(Item) bool) bool {
+// The children are visited in key order (rev=0) or in reverse order (rev=1).
+func (t *Dict[V]) iterate(p Ref[V], rev byte, h func(Item[V]) bool) bool {
 	if p.node != nil {
-		return t.iterate(p.node.child[0], h) && t.iterate(p.node.child[1], h)
+		return t.iterate(p.node.child[rev], rev, h) && t.iterate(p.node.child[1-rev], rev, h)
 	}
 	return h(p.Item)
 }
 
 // Keys returns all keys, as a slice of []byte, in a sorted order.
-func (t *Dict) Keys() [][]byte {
+func (t *Dict[V]) Keys() [][]byte {
+	return t.keys(0)
+}
+
+// KeysReverse returns all keys, as a slice of []byte, in a reverse sorted order.
+func (t *Dict[V]) KeysReverse() [][]byte {
+	return t.keys(1)
+}
+
+// keys collects all keys in key order (rev=0) or in reverse order (rev=1).
+func (t *Dict[V]) keys(rev byte) [][]byte {
 	keys := make([][]byte, 0, t.size)
 
 	// empty tree?
@@ -516,7 +768,7 @@ func (t *Dict) Keys() [][]byte {
 	}
 
 	// Walk the tree without function recursion
-	to_visit := make([]*Ref, 1)
+	to_visit := make([]*Ref[V], 1)
 
 	// Walk the left side of the root
 	p := &t.root
@@ -533,23 +785,33 @@ func (t *Dict) Keys() [][]byte {
 			keys = append(keys, p.Key)
 		} else {
 			// unshift the children and continue
-			to_visit = append(to_visit, &p.node.child[1], &p.node.child[0])
+			to_visit = append(to_visit, &p.node.child[1-rev], &p.node.child[rev])
 		}
 	}
 	return keys
 }
 
-// Items returns a []Item slice sorted by count (descending)
-func (t *Dict) Items() (items ItemSlice) {
+// Items returns all items, as an ItemSlice, in a sorted key order.
+func (t *Dict[V]) Items() ItemSlice[V] {
+	return t.items(0)
+}
+
+// ItemsReverse returns all items, as an ItemSlice, in a reverse sorted key order.
+func (t *Dict[V]) ItemsReverse() ItemSlice[V] {
+	return t.items(1)
+}
+
+// items collects all items in key order (rev=0) or in reverse order (rev=1).
+func (t *Dict[V]) items(rev byte) (items ItemSlice[V]) {
 	// empty tree?
 	if t.Empty() {
 		return items
 	}
 
-	items = make(ItemSlice, 0, t.size)
+	items = make(ItemSlice[V], 0, t.size)
 
 	// Walk the tree without function recursion
-	to_visit := make([]*Ref, 1)
+	to_visit := make([]*Ref[V], 1)
 
 	// Walk the left side of the root
 	p := &t.root
@@ -566,29 +828,45 @@ func (t *Dict) Items() (items ItemSlice) {
 			items = append(items, p.Item)
 		} else {
 			// unshift the children and continue
-			to_visit = append(to_visit, &p.node.child[1], &p.node.child[0])
+			to_visit = append(to_visit, &p.node.child[1-rev], &p.node.child[rev])
 		}
 	}
 
 	return items
 }
 
-func (t *Dict) DebugDump() {
-	t.debug_dump(&t.root, "T:", 0, "")
-}
-
-func (t *Dict) debug_dump(ref *Ref, tag string, off int, indent string) {
-	if ref.node == nil {
-		critbyte := "  [        ]"
-		if off < len(ref.Key) {
-			critbyte = fmt.Sprintf("%c [%08b]", ref.Key[off], ref.Key[off])
+// critbit finds the first bit the key differs from another key in.
+// It returns the offset of the differing byte, the bit mask and the
+// direction of the other key. differ is false if the keys are equal.
+func critbit(key, other []byte) (off int, bit, odir byte, differ bool) {
+	var ch byte
+	var klen = len(key)
+	var olen = len(other)
+	// find differing byte
+	for off = 0; off < klen; off++ {
+		if ch = 0; off < olen {
+			ch = other[off]
+		}
+		if keych := key[off]; ch != keych {
+			bit = ch ^ keych
+			goto ByteFound
 		}
-		fmt.Printf("%s%s LEAF byte=%s key=%q val=%v\n", indent, tag, critbyte, ref.Key, ref.Val)
-	} else {
-		fmt.Printf("%s%s NODE off=%v mask=%08b\n", indent, tag, ref.node.off, ref.node.bit)
-
-		t.debug_dump(&ref.node.child[0], "L:", ref.node.off, indent + "  ")
-		t.debug_dump(&ref.node.child[1], "R:", ref.node.off, indent + "  ")
 	}
+	if off < olen {
+		ch = other[off]
+		bit = ch
+		goto ByteFound
+	}
+	return
+ByteFound:
+	// find differing bit
+	bit |= bit >> 1
+	bit |= bit >> 2
+	bit |= bit >> 4
+	bit = bit &^ (bit >> 1)
+	if ch&bit != 0 {
+		odir = 1
+	}
+	differ = bit != 0
+	return
 }
-
@@ -3,8 +3,21 @@ package dict
 import "testing"
 import "bytes"
 
-func keys(tr *Dict) (s [][]byte) {
-	tr.Iter(nil, func(item Item) bool {
+// testDictKeys are the keys of the common fixture: shared prefixes of
+// different lengths on both sides of the first crit bit
+var testDictKeys = []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}
+
+// testDict makes a dict of keys valued by their positions
+func testDict(keys ...string) *Dict[int] {
+	tr := NewDict[int]()
+	for i, s := range keys {
+		tr.Set([]byte(s), i)
+	}
+	return tr
+}
+
+func keys[V any](tr *Dict[V]) (s [][]byte) {
+	tr.Iter(nil, func(item Item[V]) bool {
 		s = append(s, item.Key)
 		return true
 	})
@@ -12,15 +25,15 @@ func keys(tr *Dict) (s [][]byte) {
 }
 
 func Test_EmptyDict(t *testing.T) {
-	tr := NewDict()
+	tr := NewDict[int]()
 	if keys(tr) != nil {
 		t.Error("must be empty")
 	}
 	if _, ok := tr.Get([]byte("a")); ok {
 		t.Errorf("wrong .Get() result: expected false, got %v", ok)
 	}
-	if old := tr.Del([]byte("a")); old != nil {
-		t.Errorf("wrong .Del() result: expected nil, got %v", old)
+	if old, ok := tr.Del([]byte("a")); ok {
+		t.Errorf("wrong .Del() result: expected (0, false), got (%v, %v)", old, ok)
 	}
 }
 
@@ -47,11 +60,11 @@ func Test_KeyOrder(t *testing.T) {
 		},
 	}
 	for i, test := range tests {
-		tr := NewDict()
+		tr := NewDict[int]()
 		for _, s := range test.ins {
 			t.Logf("inserting %v\n", s)
 			tr.Set([]byte(s), 1)
-			var v interface{}
+			var v int
 			var ok bool
 			if v, ok = tr.Get([]byte(s)); v == 1 && ok {
 				continue
@@ -75,28 +88,29 @@ func Test_KeyOrder(t *testing.T) {
 		}
 		for j := len(res) - 1; j >= 0; j-- {
 			t.Logf("deleting %s\n", res[j])
-			var c interface{}
-			if c = tr.Del(res[j]); c == 1 {
+			var c int
+			var ok bool
+			if c, ok = tr.Del(res[j]); c == 1 && ok {
 				continue
 			}
-			t.Errorf("test %d: wrong .Del(%q) result, expected 1, got %v", i, res[j], c)
+			t.Errorf("test %d: wrong .Del(%q) result, expected (1, true), got (%v, %v)", i, res[j], c, ok)
 			return
 		}
 	}
 }
 
 func Test_DeleteUnknownKey(t *testing.T) {
-	tr := NewDict()
-	if c := tr.Set([]byte("aa"), 2); c != nil {
-		t.Error("wrong result when setting into an empty tree: %v", c)
+	tr := NewDict[int]()
+	if c, ok := tr.Set([]byte("aa"), 2); ok {
+		t.Errorf("wrong result when setting into an empty tree: %v", c)
 	}
-	if c := tr.Del([]byte("ab")); c != nil {
+	if c, ok := tr.Del([]byte("ab")); ok {
 		t.Errorf("wrong result when deleting an unknown key: %v", c)
 	}
 }
 
 func Test_Iter(t *testing.T) {
-	tr := NewDict()
+	tr := NewDict[int]()
 	keys := []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}
 
 	for _, s := range keys {
@@ -115,7 +129,7 @@ func Test_Iter(t *testing.T) {
 	}
 	for i, test := range tests {
 		s := test.keys
-		tr.Iter([]byte(test.prefix), func(item Item) bool {
+		tr.Iter([]byte(test.prefix), func(item Item[int]) bool {
 			if len(s) < 1 {
 				t.Errorf("test %d: superfluous key %q", i, string(item.Key))
 				return true
@@ -147,7 +161,7 @@ func testKeysEq(a, b [][]byte) bool {
 }
 
 func Test_Keys0(t *testing.T) {
-	tr := NewDict()
+	tr := NewDict[string]()
 	expected := [][]byte{}
 
 	returned_keys := tr.Keys()
@@ -157,7 +171,7 @@ func Test_Keys0(t *testing.T) {
 }
 
 func Test_Keys1(t *testing.T) {
-	tr := NewDict()
+	tr := NewDict[string]()
 	orig_keys := []string{"aa"}
 	expected := [][]byte{[]byte("aa")}
 
@@ -171,7 +185,7 @@ func Test_Keys1(t *testing.T) {
 }
 
 func Test_KeysMany(t *testing.T) {
-	tr := NewDict()
+	tr := NewDict[string]()
 	orig_keys := []string{"zz", "dd", "yy", "cc", "xx", "bb", "ww", "aa"}
 	expected := [][]byte{
 		[]byte("aa"), []byte("bb"), []byte("cc"), []byte("dd"),
@@ -187,9 +201,9 @@ func Test_KeysMany(t *testing.T) {
 }
 
 func Test_Items(t *testing.T) {
-	tr := NewDict()
+	tr := NewDict[int]()
 	keys := []string{"a","b","c","a","bb","ccc","a","ccc"}
-	expected := ItemSlice{
+	expected := ItemSlice[int]{
 		{[]byte("a"), 6}, {[]byte("b"), 1}, {[]byte("bb"), 4},
 		{[]byte("c"), 2}, {[]byte("ccc"),7},
 	}
@@ -214,10 +228,10 @@ func Test_Items(t *testing.T) {
 }
 
 func Test_Merge(t *testing.T) {
-	a := NewDict(ItemSlice{{[]byte("ABC"),"@"}, {[]byte("DEF"),'H'}}...)
-	b := NewDict(ItemSlice{{[]byte("ABC"),-1},  {[]byte("GHI"),0.3}}...)
+	a := NewDict(ItemSlice[any]{{[]byte("ABC"),"@"}, {[]byte("DEF"),'H'}}...)
+	b := NewDict(ItemSlice[any]{{[]byte("ABC"),-1},  {[]byte("GHI"),0.3}}...)
 
-	expected := ItemSlice{
+	expected := ItemSlice[any]{
 		{[]byte("ABC"), -1}, {[]byte("DEF"), 'H'}, {[]byte("GHI"), 0.3},
 	}
 
@@ -237,3 +251,271 @@ func Test_Merge(t *testing.T) {
 		}
 	}
 }
+
+func Test_Replace(t *testing.T) {
+	tr := NewDict[int]()
+	key := []byte("abc")
+
+	// the first call sees no previous value
+	prev, ok := tr.Replace(key, func(old int, exists bool) int {
+		if exists {
+			t.Errorf("key %q must not exist yet", key)
+		}
+		return old + 10
+	})
+	if prev != 0 || ok {
+		t.Errorf("wrong .Replace() result: expected (0, false), got (%v, %v)", prev, ok)
+	}
+	// the second call sees the stored value
+	prev, ok = tr.Replace(key, func(old int, exists bool) int {
+		if !exists || old != 10 {
+			t.Errorf("wrong args: expected (10, true), got (%v, %v)", old, exists)
+		}
+		return old + 1
+	})
+	if prev != 10 || !ok {
+		t.Errorf("wrong .Replace() result: expected (10, true), got (%v, %v)", prev, ok)
+	}
+	if v, _ := tr.Get(key); v != 11 {
+		t.Errorf("wrong .Get() result: expected 11, got %v", v)
+	}
+}
+
+func Test_ZeroValue(t *testing.T) {
+	tr := NewDict[*int]()
+	tr.Set([]byte("nil"), nil)
+
+	// a stored zero value must be distinguishable from a missing key
+	if v, ok := tr.Get([]byte("nil")); v != nil || !ok {
+		t.Errorf("wrong .Get() result: expected (nil, true), got (%v, %v)", v, ok)
+	}
+	if v, ok := tr.Del([]byte("nil")); v != nil || !ok {
+		t.Errorf("wrong .Del() result: expected (nil, true), got (%v, %v)", v, ok)
+	}
+	if _, ok := tr.Get([]byte("nil")); ok {
+		t.Errorf("wrong .Get() result after .Del(): expected false, got %v", ok)
+	}
+}
+
+func Test_IterRange(t *testing.T) {
+	tr := NewDict[int]()
+	keys := []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}
+
+	for i, s := range keys {
+		tr.Set([]byte(s), i)
+	}
+	tests := []struct {
+		lo, hi []byte
+		opts   RangeOpts
+		keys   []string
+	}{
+		{nil, nil, RangeOpts{}, keys},
+		{nil, nil, RangeOpts{Reverse: true}, []string{"bbb", "bba", "bb", "ba", "ab", "aab", "aaa", "aa"}},
+		{[]byte("aab"), []byte("bb"), RangeOpts{}, []string{"aab", "ab", "ba", "bb"}},
+		{[]byte("aab"), []byte("bb"), RangeOpts{LoExclusive: true, HiExclusive: true}, []string{"ab", "ba"}},
+		{[]byte("aab"), []byte("bb"), RangeOpts{HiExclusive: true, Reverse: true}, []string{"ba", "ab", "aab"}},
+		{[]byte("ac"), []byte("b"), RangeOpts{}, nil},
+		{[]byte("a"), []byte("ab"), RangeOpts{}, []string{"aa", "aaa", "aab", "ab"}},
+		{[]byte("b"), nil, RangeOpts{}, []string{"ba", "bb", "bba", "bbb"}},
+		{nil, []byte("aab"), RangeOpts{LoExclusive: true, Reverse: true}, []string{"aab", "aaa", "aa"}},
+		{[]byte("bbb"), []byte("bbb"), RangeOpts{}, []string{"bbb"}},
+		{[]byte("bbb"), []byte("bbb"), RangeOpts{LoExclusive: true}, nil},
+		{[]byte("c"), nil, RangeOpts{Reverse: true}, nil},
+		{[]byte("bb"), []byte("aa"), RangeOpts{}, nil},
+	}
+	for i, test := range tests {
+		var got []string
+		tr.IterRange(test.lo, test.hi, test.opts, func(item Item[int]) bool {
+			got = append(got, string(item.Key))
+			return true
+		})
+		if len(got) != len(test.keys) {
+			t.Errorf("test %d: got %q, expected %q", i, got, test.keys)
+			continue
+		}
+		for j := range got {
+			if got[j] != test.keys[j] {
+				t.Errorf("test %d: got %q, expected %q", i, got, test.keys)
+				break
+			}
+		}
+	}
+
+	// abort after the first item
+	n := 0
+	if tr.IterRange([]byte("a"), []byte("b"), RangeOpts{}, func(Item[int]) bool { n++; return false }) || n != 1 {
+		t.Errorf("IterRange must stop when the handler returns false (%d calls)", n)
+	}
+}
+
+func Test_IterReverse(t *testing.T) {
+	tr := NewDict[int]()
+	keys := []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}
+
+	for _, s := range keys {
+		tr.Set([]byte(s), 1)
+	}
+	tests := []struct {
+		prefix string
+		keys   []string
+	}{
+		{"", []string{"bbb", "bba", "bb", "ba", "ab", "aab", "aaa", "aa"}},
+		{"a", []string{"ab", "aab", "aaa", "aa"}},
+		{"bb", []string{"bbb", "bba", "bb"}},
+		{"aaa", []string{"aaa"}},
+		{"aaaa", nil},
+		{"c", nil},
+	}
+	for i, test := range tests {
+		s := test.keys
+		tr.IterReverse([]byte(test.prefix), func(item Item[int]) bool {
+			if len(s) < 1 {
+				t.Errorf("test %d: superfluous key %q", i, string(item.Key))
+				return true
+			}
+			if ! bytes.Equal([]byte(s[0]), item.Key) {
+				t.Errorf("test %d: got key %q, expected %q", i, string(item.Key), s[0])
+			}
+			s = s[1:]
+			return true
+		})
+		if len(s) > 0 {
+			t.Errorf("test %d: missing keys %q", i, s)
+		}
+	}
+
+	// abort after two keys
+	n := 0
+	if tr.IterReverse(nil, func(item Item[int]) bool { n++; return n < 2 }) || n != 2 {
+		t.Errorf("IterReverse must stop when the handler returns false (%d calls)", n)
+	}
+}
+
+func Test_KeysReverse(t *testing.T) {
+	tr := NewDict[int]()
+	orig_keys := []string{"zz", "dd", "yy", "cc", "xx", "bb", "ww", "aa"}
+	expected := [][]byte{
+		[]byte("zz"), []byte("yy"), []byte("xx"), []byte("ww"),
+		[]byte("dd"), []byte("cc"), []byte("bb"), []byte("aa")}
+
+	if returned_keys := tr.KeysReverse(); len(returned_keys) != 0 {
+		t.Errorf("Got: %q", returned_keys)
+	}
+	for _, s := range orig_keys {
+		tr.Set([]byte(s), 1)
+	}
+	returned_keys := tr.KeysReverse()
+	if ! testKeysEq(returned_keys, expected) {
+		t.Errorf("Got: %q", returned_keys)
+	}
+}
+
+func Test_ItemsReverse(t *testing.T) {
+	tr := NewDict[int]()
+	for i, s := range []string{"b", "a", "c", "bb"} {
+		tr.Set([]byte(s), i)
+	}
+	expected := ItemSlice[int]{
+		{[]byte("c"), 2}, {[]byte("bb"), 3}, {[]byte("b"), 0}, {[]byte("a"), 1},
+	}
+	items := tr.ItemsReverse()
+	if len(items) != len(expected) {
+		t.Fatalf("wrong number of items: expected %v, got %v", len(expected), len(items))
+	}
+	for i, item := range items {
+		if ! bytes.Equal(item.Key, expected[i].Key) || item.Val != expected[i].Val {
+			t.Errorf("item %d: expected %q=%v, got %q=%v", i, expected[i].Key, expected[i].Val, item.Key, item.Val)
+		}
+	}
+}
+
+func Test_MinMax(t *testing.T) {
+	tr := NewDict[int]()
+	if _, ok := tr.Min(nil); ok {
+		t.Error("Min() of an empty tree must fail")
+	}
+	if _, ok := tr.PopMax(nil); ok {
+		t.Error("PopMax() of an empty tree must fail")
+	}
+	for _, s := range []string{"ba", "aab", "bbb", "aa", "ab", "bb", "aaa", "bba"} {
+		tr.Set([]byte(s), len(s))
+	}
+	tests := []struct {
+		prefix   string
+		min, max string
+	}{
+		{"",    "aa",  "bbb"},
+		{"a",   "aa",  "ab"},
+		{"aa",  "aa",  "aab"},
+		{"b",   "ba",  "bbb"},
+		{"bba", "bba", "bba"},
+		{"c",   "",    ""},
+		{"aac", "",    ""},
+	}
+	for i, test := range tests {
+		if item, _ := tr.Min([]byte(test.prefix)); string(item.Key) != test.min {
+			t.Errorf("test %d: Min(%q) -> %q, expected %q", i, test.prefix, item.Key, test.min)
+		}
+		if item, _ := tr.Max([]byte(test.prefix)); string(item.Key) != test.max {
+			t.Errorf("test %d: Max(%q) -> %q, expected %q", i, test.prefix, item.Key, test.max)
+		}
+	}
+
+	// pop the greatest "a..." keys, then drain the rest from the left
+	expected := []string{"ab", "aab", "aaa", "aa", "ba", "bb", "bba", "bbb"}
+	for i, exp := range expected {
+		pop := tr.PopMin
+		if i < 4 {
+			pop = tr.PopMax
+		}
+		prefix := []byte("a")
+		if i >= 4 {
+			prefix = nil
+		}
+		item, ok := pop(prefix)
+		if ! ok || string(item.Key) != exp {
+			t.Errorf("pop %d: got (%q, %v), expected %q", i, item.Key, ok, exp)
+		}
+		if item.Val != len(item.Key) {
+			t.Errorf("wrong value of %q: %v", item.Key, item.Val)
+		}
+		if tr.Len() != len(expected) - i - 1 {
+			t.Errorf("pop %d: wrong length %d", i, tr.Len())
+		}
+	}
+	if ! tr.Empty() {
+		t.Errorf("the tree must be empty, got %q", tr.Keys())
+	}
+}
+
+func Test_DeletePrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		n      int
+		rest   []string
+	}{
+		{"c",    0, []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}},
+		{"aac",  0, []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}},
+		{"aa",   3, []string{"ab", "ba", "bb", "bba", "bbb"}},
+		{"bba",  1, []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bbb"}},
+		{"b",    4, []string{"aa", "aaa", "aab", "ab"}},
+		{"",     8, nil},
+	}
+	for i, test := range tests {
+		tr := NewDict[int]()
+		for _, s := range []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"} {
+			tr.Set([]byte(s), len(s))
+		}
+		if n := tr.DeletePrefix([]byte(test.prefix)); n != test.n {
+			t.Errorf("test %d: DeletePrefix(%q) -> %d, expected %d", i, test.prefix, n, test.n)
+		}
+		var expected [][]byte
+		for _, s := range test.rest {
+			expected = append(expected, []byte(s))
+		}
+		if keys := tr.Keys(); ! testKeysEq(keys, expected) || tr.Len() != len(expected) {
+			t.Errorf("test %d: got %q (len %d), expected %q", i, keys, tr.Len(), test.rest)
+		}
+		checkSizes(t, &tr.root)
+	}
+}
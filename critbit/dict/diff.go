@@ -0,0 +1,118 @@
+package dict
+
+import "bytes"
+import "reflect"
+
+
+type DiffKind byte
+
+const (
+	// Added: the key is only in the other dict
+	Added DiffKind = iota + 1
+	// Removed: the key is only in this dict
+	Removed
+	// Changed: the key is in both dicts with different values
+	Changed
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Changed:
+		return "Changed"
+	}
+	return "DiffKind(?)"
+}
+
+// DiffEntry describes a difference of a key. Old is set unless the key was
+// Added, New is set unless the key was Removed.
+type DiffEntry[V any] struct {
+	Kind DiffKind
+	Key  []byte
+	Old  V
+	New  V
+}
+
+// Diff calls a handler for every key which differs between this (old) dict
+// and another (new) one in key order. Values of common keys are compared
+// with eq (reflect.DeepEqual if nil). Both trees are walked together and
+// subtrees shared by them (see PersistentDict) are skipped as a whole.
+// It returns whether all differences were reported.
+func (t *Dict[V]) Diff(other *Dict[V], eq func(a, b V) bool, handler func(DiffEntry[V]) bool) bool {
+	if eq == nil {
+		eq = func(a, b V) bool { return reflect.DeepEqual(a, b) }
+	}
+	// pending subtrees of both trees in reverse key order (the top is the least)
+	var old_refs, new_refs []Ref[V]
+	if ! t.Empty() {
+		old_refs = append(old_refs, t.root)
+	}
+	if other != nil && ! other.Empty() {
+		new_refs = append(new_refs, other.root)
+	}
+	for len(old_refs) > 0 && len(new_refs) > 0 {
+		a := old_refs[len(old_refs)-1]
+		b := new_refs[len(new_refs)-1]
+		switch {
+		case a.node != nil && a.node == b.node:
+			// shared subtree
+			old_refs = old_refs[:len(old_refs)-1]
+			new_refs = new_refs[:len(new_refs)-1]
+		case a.node != nil && (b.node == nil || ! below(a.node, b.node)):
+			// expand the shallower node first so that shared subtrees line up
+			old_refs = append(old_refs[:len(old_refs)-1], a.node.child[1], a.node.child[0])
+			if b.node != nil && a.node.off == b.node.off && a.node.bit == b.node.bit {
+				new_refs = append(new_refs[:len(new_refs)-1], b.node.child[1], b.node.child[0])
+			}
+		case b.node != nil:
+			new_refs = append(new_refs[:len(new_refs)-1], b.node.child[1], b.node.child[0])
+		default:
+			// two leaves
+			var e DiffEntry[V]
+			switch cmp := bytes.Compare(a.Key, b.Key); {
+			case cmp < 0:
+				e = DiffEntry[V]{Kind:Removed, Key:a.Key, Old:a.Val}
+				old_refs = old_refs[:len(old_refs)-1]
+			case cmp > 0:
+				e = DiffEntry[V]{Kind:Added, Key:b.Key, New:b.Val}
+				new_refs = new_refs[:len(new_refs)-1]
+			default:
+				old_refs = old_refs[:len(old_refs)-1]
+				new_refs = new_refs[:len(new_refs)-1]
+				if eq(a.Val, b.Val) {
+					continue
+				}
+				e = DiffEntry[V]{Kind:Changed, Key:a.Key, Old:a.Val, New:b.Val}
+			}
+			if ! handler(e) {
+				return false
+			}
+		}
+	}
+	// the rest is only in one of the trees
+	for i := len(old_refs) - 1; i >= 0; i-- {
+		removed := func(item Item[V]) bool {
+			return handler(DiffEntry[V]{Kind:Removed, Key:item.Key, Old:item.Val})
+		}
+		if ! t.iterate(old_refs[i], 0, removed) {
+			return false
+		}
+	}
+	for i := len(new_refs) - 1; i >= 0; i-- {
+		added := func(item Item[V]) bool {
+			return handler(DiffEntry[V]{Kind:Added, Key:item.Key, New:item.Val})
+		}
+		if ! t.iterate(new_refs[i], 0, added) {
+			return false
+		}
+	}
+	return true
+}
+
+// below reports whether node a tests a later crit bit than node b
+func below[V any](a, b *Node[V]) bool {
+	return a.off > b.off || a.off == b.off && a.bit < b.bit
+}
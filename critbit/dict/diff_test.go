@@ -0,0 +1,81 @@
+package dict
+
+import "testing"
+import "fmt"
+
+func diffString[V any](t *Dict[V], other *Dict[V]) (res []string) {
+	t.Diff(other, nil, func(e DiffEntry[V]) bool {
+		res = append(res, fmt.Sprintf("%v %s %v %v", e.Kind, e.Key, e.Old, e.New))
+		return true
+	})
+	return
+}
+
+func Test_Diff(t *testing.T) {
+	a := NewDict[int]()
+	b := NewDict[int]()
+	for i, s := range []string{"aa", "aaa", "aab", "ab", "ba", "bb"} {
+		a.Set([]byte(s), i)
+		b.Set([]byte(s), i)
+	}
+	if res := diffString(a, b); len(res) != 0 {
+		t.Errorf("equal dicts must have no diff, got %q", res)
+	}
+	a.Del([]byte("aab"))
+	b.Del([]byte("ba"))
+	a.Set([]byte("bb"), 10)
+	b.Set([]byte("c"), 7)
+	b.Set([]byte("a"), 8)
+
+	expected := []string{"Added a 0 8", "Added aab 0 2", "Removed ba 4 0", "Changed bb 10 5", "Added c 0 7"}
+	if res := diffString(a, b); fmt.Sprint(res) != fmt.Sprint(expected) {
+		t.Errorf("wrong diff:\n  got %q\n  exp %q", res, expected)
+	}
+	// abort after the first entry
+	n := 0
+	if a.Diff(b, nil, func(DiffEntry[int]) bool { n++; return false }) || n != 1 {
+		t.Errorf("Diff must stop when the handler returns false (%d calls)", n)
+	}
+	// custom eq and empty dicts
+	if res := diffString(NewDict[int](), NewDict[int]()); len(res) != 0 {
+		t.Errorf("empty dicts must have no diff, got %q", res)
+	}
+	if res := diffString(NewDict[int](), a); len(res) != a.Len() || res[0] != "Added aa 0 0" {
+		t.Errorf("wrong diff with an empty dict: %q", res)
+	}
+	n = 0
+	a.Diff(b, func(x, y int) bool { return true }, func(e DiffEntry[int]) bool {
+		if e.Kind == Changed {
+			t.Errorf("eq must suppress changes, got %v", e)
+		}
+		n++
+		return true
+	})
+	if n != 4 {
+		t.Errorf("expected 4 entries, got %d", n)
+	}
+}
+
+func Test_PersistentDiff(t *testing.T) {
+	v1 := NewPersistentDict[int]()
+	for i := 0; i < 1000; i++ {
+		v1 = v1.Set([]byte(fmt.Sprintf("key%04d", i)), i)
+	}
+	v2 := v1.Set([]byte("key0500"), -1).Del([]byte("key0007")).Set([]byte("key5"), 5)
+
+	visited := 0
+	eq := func(a, b int) bool { visited++; return a == b }
+	var res []string
+	v1.Diff(v2, eq, func(e DiffEntry[int]) bool {
+		res = append(res, fmt.Sprintf("%v %s", e.Kind, e.Key))
+		return true
+	})
+	expected := []string{"Removed key0007", "Changed key0500", "Added key5"}
+	if fmt.Sprint(res) != fmt.Sprint(expected) {
+		t.Errorf("wrong diff:\n  got %q\n  exp %q", res, expected)
+	}
+	// only the leaves next to the copied paths are compared
+	if visited > 100 {
+		t.Errorf("shared subtrees must be skipped, %d values compared", visited)
+	}
+}
@@ -0,0 +1,69 @@
+package dict
+
+import "testing"
+import "bytes"
+import "errors"
+
+func Test_Dump(t *testing.T) {
+	tr := NewDict(Item[int]{[]byte("a"), 1}, Item[int]{[]byte("b"), 2}, Item[int]{[]byte("c\"\\"), 3})
+
+	tests := []struct {
+		opts DumpOpts
+		exp  string
+	}{
+		{DumpOpts{},
+			"T: NODE off=0 mask=00000010 size=3\n" +
+			"  L: LEAF byte=0x61 [01100001] key=\"a\" val=1\n" +
+			"  R: NODE off=0 mask=00000001 size=2\n" +
+			"    L: LEAF byte=0x62 [01100010] key=\"b\" val=2\n" +
+			"    R: LEAF byte=0x63 [01100011] key=\"c\\\"\\\\\" val=3\n"},
+		{DumpOpts{MaxDepth: 1, HideValues: true},
+			"T: NODE off=0 mask=00000010 size=3\n" +
+			"  L: LEAF byte=0x61 [01100001] key=\"a\"\n" +
+			"  R: NODE off=0 mask=00000001 size=2\n" +
+			"    ...\n"},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		if err := tr.Dump(&buf, test.opts); err != nil || buf.String() != test.exp {
+			t.Errorf("test %d: got (%v)\n%s", i, err, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if NewDict[int]().Dump(&buf, DumpOpts{}); buf.String() != "T: EMPTY\n" {
+		t.Errorf("got %q for an empty dict", buf.String())
+	}
+	if err := tr.Dump(failWriter{}, DumpOpts{}); err != errFail {
+		t.Errorf("expected a write error, got %v", err)
+	}
+}
+
+func Test_WriteDOT(t *testing.T) {
+	tr := NewDict(Item[int]{[]byte("a"), 1}, Item[int]{[]byte("b"), 2}, Item[int]{[]byte("c\"\\"), 3})
+	exp := `digraph dict {
+	node [fontname="monospace"];
+	n0 [shape=ellipse, label="off=0 mask=00000010\nsize=3"];
+	n1 [shape=box, label="\"a\"\n1"];
+	n0 -> n1 [label="0"];
+	n2 [shape=ellipse, label="off=0 mask=00000001\nsize=2"];
+	n3 [shape=box, label="\"b\"\n2"];
+	n2 -> n3 [label="0"];
+	n4 [shape=box, label="\"c\\\"\\\\\"\n3"];
+	n2 -> n4 [label="1"];
+	n0 -> n2 [label="1"];
+}
+`
+	var buf bytes.Buffer
+	if err := tr.WriteDOT(&buf); err != nil || buf.String() != exp {
+		t.Errorf("got (%v)\n%s", err, buf.String())
+	}
+}
+
+var errFail = errors.New("write failed")
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errFail
+}
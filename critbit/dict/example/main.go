@@ -6,7 +6,7 @@ import "analyzers/lib/critbit/dict"
 
 
 func main() {
-	d := dict.NewDict()
+	d := dict.NewDict[int]()
 	d.Set([]byte("c"),  1)
 	//d.Set([]byte("a"),  2)
 	d.Set([]byte("a1"), 3)
@@ -17,25 +17,14 @@ func main() {
 
 	d.DebugDump()
 
-	var a, b  *dict.RefPath
-
-	//a,b = d.FindPathRange([]byte(""));		fmt.Printf("R()     -> %v .. %v\n", a, b)
-	a,b  = d.FindPathRange([]byte("a"));	fmt.Printf("R(a)    -> %v .. %v\n", a, b)
-
-	if a != nil {
-		cur, end := a.GetLeaf(), b.GetLeaf()
-		for {
-			fmt.Printf("%s\n", cur.Key)
-			if cur == end {
-				break
-			}
-			cur = a.TrackNext()
-		}
+	c := d.Cursor()
+	for ok := c.SeekGE([]byte("a")); ok && c.Key()[0] == 'a'; ok = c.Next() {
+		fmt.Printf("%s\n", c.Key())
 	}
 
 	println("------")
 
-	visitor := func(item dict.Item) bool {
+	visitor := func(item dict.Item[int]) bool {
 		fmt.Printf("%s\n", item.Key)
 		return true
 	}
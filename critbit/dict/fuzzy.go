@@ -0,0 +1,85 @@
+package dict
+
+
+// FuzzySearch calls a handler for every item whose key is within maxDist
+// edits (byte insertions, deletions and substitutions) from the query,
+// along with the edit distance, in key order. The tree is walked with rows
+// of the Levenshtein matrix and subtrees whose shared prefix is already too
+// far from the query are skipped. It returns whether all matching items
+// were reported.
+func (t *Dict[V]) FuzzySearch(query []byte, maxDist int, handler func(Item[V], int) bool) bool {
+	if t.Empty() || maxDist < 0 {
+		return true
+	}
+	return t.fuzzy(t.root, t.root.first(), 0, newLevenshtein(query, maxDist), handler)
+}
+
+// fuzzy visits the subtree at p whose leftmost key is first; depth is the
+// length of the key prefix already accounted for by the levenshtein rows.
+func (t *Dict[V]) fuzzy(p Ref[V], first []byte, depth int, lv *levenshtein, h func(Item[V], int) bool) bool {
+	if p.node == nil {
+		lv.extend(p.Key, depth, len(p.Key))
+		if dist := lv.dist(len(p.Key)); dist <= lv.max {
+			return h(p.Item, dist)
+		}
+		return true
+	}
+	// all keys of the subtree share the bytes of the leftmost one before the
+	// crit byte. The left child has the same leftmost key, the right one is
+	// descended to find its own, so a walk passes every node once.
+	m := min(p.node.off, len(first))
+	if lv.extend(first, depth, m) > lv.max {
+		return true
+	}
+	right := &p.node.child[1]
+	return t.fuzzy(p.node.child[0], first, m, lv, h) && t.fuzzy(*right, right.first(), m, lv, h)
+}
+
+
+// levenshtein keeps rows of the edit distance matrix between the query and
+// prefixes of a key: row i holds distances for the key prefix of length i.
+type levenshtein struct {
+	query []byte
+	max   int
+	rows  []int
+}
+
+func newLevenshtein(query []byte, max int) *levenshtein {
+	lv := &levenshtein{query: query, max: max}
+	for j := 0; j <= len(query); j++ {
+		lv.rows = append(lv.rows, j)
+	}
+	return lv
+}
+
+// extend computes rows from+1..to for the key bytes and returns the minimum
+// of the last row (no longer key can get closer than that).
+func (lv *levenshtein) extend(key []byte, from, to int) int {
+	w := len(lv.query) + 1
+	if need := (to + 1) * w; len(lv.rows) < need {
+		lv.rows = append(lv.rows, make([]int, need - len(lv.rows))...)
+	}
+	for i := from + 1; i <= to; i++ {
+		prev := lv.rows[(i-1)*w : i*w]
+		row  := lv.rows[i*w : (i+1)*w]
+		row[0] = i
+		for j := 1; j < w; j++ {
+			cost := 1
+			if lv.query[j-1] == key[i-1] {
+				cost = 0
+			}
+			row[j] = min(prev[j-1] + cost, prev[j] + 1, row[j-1] + 1)
+		}
+	}
+	low := lv.rows[to*w]
+	for _, d := range lv.rows[to*w : (to+1)*w] {
+		low = min(low, d)
+	}
+	return low
+}
+
+// dist returns the distance between the query and the key prefix of length i
+func (lv *levenshtein) dist(i int) int {
+	w := len(lv.query) + 1
+	return lv.rows[i*w + w - 1]
+}
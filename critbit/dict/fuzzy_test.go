@@ -0,0 +1,33 @@
+package dict
+
+import "testing"
+import "fmt"
+
+func Test_FuzzySearch(t *testing.T) {
+	tr := NewDict[int]()
+	for i, s := range []string{"apple", "apply", "ample", "maple", "app", "applesauce", "banana", "a"} {
+		tr.Set([]byte(s), i)
+	}
+	tests := []struct {
+		query string
+		max   int
+		res   []string
+	}{
+		{"apple", 0, []string{"apple=0:0"}},
+		{"apple", 1, []string{"ample=2:1", "apple=0:0", "apply=1:1"}},
+		{"apple", 2, []string{"ample=2:1", "app=4:2", "apple=0:0", "apply=1:1", "maple=3:2"}},
+		{"applesauc", 1, []string{"applesauce=5:1"}},
+		{"",      1, []string{"a=7:1"}},
+		{"xyz",   2, nil},
+	}
+	for i, test := range tests {
+		var res []string
+		tr.FuzzySearch([]byte(test.query), test.max, func(item Item[int], dist int) bool {
+			res = append(res, fmt.Sprintf("%s=%d:%d", item.Key, item.Val, dist))
+			return true
+		})
+		if fmt.Sprint(res) != fmt.Sprint(test.res) {
+			t.Errorf("test %d: FuzzySearch(%q, %d) -> %q, expected %q", i, test.query, test.max, res, test.res)
+		}
+	}
+}
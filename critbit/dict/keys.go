@@ -0,0 +1,51 @@
+package dict
+
+import "analyzers/lib/critbit/arena"
+
+
+// SetKeyMode makes the dict store keys of subsequent insertions according to
+// a mode (see the arena package). Keys stored before are not affected, use
+// Compact to copy them. Returns itself.
+func (t *Dict[V]) SetKeyMode(mode arena.Mode) *Dict[V] {
+	t.arena.SetMode(mode)
+	return t
+}
+
+// SetCopy is like Set but stores a copy of a new key regardless of the mode.
+func (t *Dict[V]) SetCopy(key []byte, val V) (prev V, ok bool) {
+	return t.replace(key, func(V, bool) V {return val}, true)
+}
+
+// Compact copies all keys into fresh arena blocks, so the space taken by
+// deleted keys is released and no key is shared with the caller anymore.
+// It only works in the arena.Copy mode and does nothing otherwise.
+func (t *Dict[V]) Compact() {
+	t.arena.Compact(t.moveAllKeys)
+}
+
+// CheckKeys returns an error if a key has been modified after insertion.
+// Mutations are only detected in the arena.Debug mode.
+func (t *Dict[V]) CheckKeys() error {
+	return t.arena.Check(func(visit func([]byte) bool) {
+		t.Iter(nil, func(item Item[V]) bool {
+			return visit(item.Key)
+		})
+	})
+}
+
+// moveAllKeys replaces all keys of the dict with their moved versions
+func (t *Dict[V]) moveAllKeys(move func([]byte) []byte) {
+	if ! t.Empty() {
+		moveKeys(&t.root, move)
+	}
+}
+
+// moveKeys replaces all keys of the subtree at p with their moved versions
+func moveKeys[V any](p *Ref[V], move func([]byte) []byte) {
+	if p.node == nil {
+		p.Key = move(p.Key)
+		return
+	}
+	moveKeys(&p.node.child[0], move)
+	moveKeys(&p.node.child[1], move)
+}
@@ -0,0 +1,50 @@
+package dict
+
+import "testing"
+import "analyzers/lib/critbit/arena"
+
+func Test_KeyModes(t *testing.T) {
+	buf := []byte("aa")
+	tr := NewDict[int]().SetKeyMode(arena.Copy)
+	for i, s := range []string{"aa", "ab", "ba"} {
+		copy(buf, s)
+		tr.Set(buf, i)
+	}
+	copy(buf, "AA")
+	if keys := tr.Keys(); len(keys) != 3 || string(keys[0]) != "aa" || string(keys[2]) != "ba" {
+		t.Errorf("copied keys must not change, got %q", keys)
+	}
+	checkSizes(t, &tr.root)
+
+	tr = NewDict[int]().SetKeyMode(arena.Debug)
+	copy(buf, "aa")
+	tr.Set(buf, 1)
+	tr.SetCopy([]byte("zz"), 2)
+	buf[0] = 'x'
+	if err := tr.CheckKeys(); err == nil {
+		t.Error("CheckKeys must detect a modified key")
+	}
+	if item, ok := tr.PopMin(nil); ! ok || string(item.Key) != "xa" || tr.CheckKeys() != nil {
+		t.Error("a removed key must not be checked")
+	}
+
+	// a buffer reused for another key is detected too
+	tr = NewDict[int]().SetKeyMode(arena.Debug)
+	tr.Set([]byte("x"), 1)
+	reused := []byte("a")
+	tr.Set(reused, 2)
+	copy(reused, "z")
+	tr.Set(reused, 3)
+	if err := tr.CheckKeys(); err == nil {
+		t.Error("CheckKeys must detect a reused key buffer")
+	}
+
+	// per-call copies without a mode
+	tr = NewDict[int]()
+	copy(buf, "aa")
+	tr.SetCopy(buf, 1)
+	buf[0] = 'x'
+	if v, ok := tr.Get([]byte("aa")); ! ok || v != 1 {
+		t.Error("SetCopy must store a copy of the key")
+	}
+}
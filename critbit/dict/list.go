@@ -0,0 +1,103 @@
+package dict
+
+import "bytes"
+import "errors"
+
+
+var ErrBadToken = errors.New("dict: bad continuation token")
+
+// List tokens are a kind byte followed by the last reported key or prefix
+const (
+	// tokenKey resumes after the key
+	tokenKey    = 'k'
+	// tokenPrefix resumes after all keys with the common prefix
+	tokenPrefix = 'p'
+)
+
+// ListEntry is either an item directly under the listed prefix or a common
+// prefix of deeper keys (IsPrefix is set and Key ends with the delimiter).
+type ListEntry[V any] struct {
+	Item[V]
+	IsPrefix bool
+}
+
+// List calls a handler for the keys with a given prefix like a listing of
+// a directory: keys containing the delimiter after the prefix are grouped by
+// their common prefix up to the delimiter, which is reported once, and the
+// rest of its subtree is skipped. An empty delimiter lists all the keys.
+//
+// If the handler aborts by returning false, List returns a token to pass to
+// a later call (with the same prefix and delimiter) to resume the listing
+// after the last entry. A nil token starts from the beginning and a nil next
+// token means the listing is complete.
+func (t *Dict[V]) List(prefix, delim, token []byte, handler func(ListEntry[V]) bool) (next []byte, err error) {
+	start, after := prefix, false
+	if token != nil {
+		if len(token) < 2 || ! bytes.HasPrefix(token[1:], prefix) {
+			return nil, ErrBadToken
+		}
+		switch start = token[1:]; token[0] {
+		case tokenKey:
+			after = true
+		case tokenPrefix:
+			if start = prefixEnd(start); start == nil {
+				return nil, nil
+			}
+		default:
+			return nil, ErrBadToken
+		}
+	}
+	var path *RefPath[V]
+	if len(start) == 0 {
+		path = t.FindPathEdge(0)
+	} else {
+		path = t.FindPathGE(start)
+	}
+	ref := path.GetLeaf()
+	if ref != nil && after && bytes.Equal(ref.Key, start) {
+		ref = path.TrackNext()
+	}
+	for ref != nil && bytes.HasPrefix(ref.Key, prefix) {
+		i := -1
+		if len(delim) > 0 {
+			i = bytes.Index(ref.Key[len(prefix):], delim)
+		}
+		if i < 0 {
+			if ! handler(ListEntry[V]{Item: ref.Item}) {
+				return listToken(tokenKey, ref.Key), nil
+			}
+			ref = path.TrackNext()
+			continue
+		}
+		common := ref.Key[:len(prefix)+i+len(delim)]
+		if ! handler(ListEntry[V]{Item: Item[V]{Key: common}, IsPrefix: true}) {
+			return listToken(tokenPrefix, common), nil
+		}
+		// skip the rest of the subtree: seek the next sibling of the prefix
+		end := prefixEnd(common)
+		if end == nil {
+			break
+		}
+		path = t.FindPathGE(end)
+		ref  = path.GetLeaf()
+	}
+	return nil, nil
+}
+
+// listToken makes a continuation token of a kind and a key
+func listToken(kind byte, key []byte) []byte {
+	return append([]byte{kind}, key...)
+}
+
+// prefixEnd returns the least key greater than all keys with a given prefix
+// (nil if there is no such key)
+func prefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			end := append([]byte(nil), prefix[:i+1]...)
+			end[i]++
+			return end
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package dict
+
+import "testing"
+import "errors"
+import "fmt"
+import "slices"
+
+// listKeys are the keys of the listing tests, like paths of a directory tree
+var listKeys = []string{"a", "a/", "a/b/c.txt", "a/b/d.txt", "a/b/e/f", "a/x.txt", "a/y/z", "a\xff/1", "b/1", "b/2/3"}
+
+// listAll lists a dict in pages of a given size and returns the entries
+func listAll(tr *Dict[int], prefix, delim string, page int) (res []string, err error) {
+	var token []byte
+	for {
+		n := 0
+		token, err = tr.List([]byte(prefix), []byte(delim), token, func(e ListEntry[int]) bool {
+			if e.IsPrefix {
+				res = append(res, string(e.Key) + "*")
+			} else {
+				res = append(res, fmt.Sprintf("%s=%d", e.Key, e.Val))
+			}
+			n++
+			return n < page
+		})
+		if err != nil || token == nil {
+			return
+		}
+	}
+}
+
+func Test_List(t *testing.T) {
+	tr := testDict(listKeys...)
+	tests := []struct {
+		prefix, delim string
+		exp           []string
+	}{
+		{"a/", "/", []string{"a/=1", "a/b/*", "a/x.txt=5", "a/y/*"}},
+		{"a/b/", "/", []string{"a/b/c.txt=2", "a/b/d.txt=3", "a/b/e/*"}},
+		{"", "/", []string{"a=0", "a/*", "a\xff/*", "b/*"}},
+		{"b", "/", []string{"b/*"}},
+		{"a/b", "", []string{"a/b/c.txt=2", "a/b/d.txt=3", "a/b/e/f=4"}},
+		{"a/", ".", []string{"a/=1", "a/b/c.*", "a/b/d.*", "a/b/e/f=4", "a/x.*", "a/y/z=6"}},
+		{"c", "/", nil},
+	}
+	for _, test := range tests {
+		for _, page := range []int{1, 2, 100} {
+			got, err := listAll(tr, test.prefix, test.delim, page)
+			if err != nil || ! slices.Equal(got, test.exp) {
+				t.Errorf("List(%q, %q) by %d -> %q, %v", test.prefix, test.delim, page, got, err)
+			}
+		}
+	}
+}
+
+func Test_ListToken(t *testing.T) {
+	tr := testDict(listKeys...)
+	var token []byte
+	token, _ = tr.List([]byte("a/"), []byte("/"), nil, func(e ListEntry[int]) bool {
+		return ! e.IsPrefix
+	})
+	if string(token) != "pa/b/" {
+		t.Errorf("wrong token %q", token)
+	}
+	// the token holds a position, so it survives modifications
+	tr.Set([]byte("a/c"), 10)
+	tr.Del([]byte("a/x.txt"))
+	var got []string
+	token, err := tr.List([]byte("a/"), []byte("/"), token, func(e ListEntry[int]) bool {
+		got = append(got, string(e.Key))
+		return true
+	})
+	if token != nil || err != nil || ! slices.Equal(got, []string{"a/c", "a/y/"}) {
+		t.Errorf("resumed List -> %q, %q, %v", got, token, err)
+	}
+
+	for _, token := range []string{"", "k", "xa/b", "pb/1"} {
+		if _, err := tr.List([]byte("a/"), []byte("/"), []byte(token), func(ListEntry[int]) bool { return true }); ! errors.Is(err, ErrBadToken) {
+			t.Errorf("expected ErrBadToken for %q, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package dict
+
+import "analyzers/lib/critbit/pattern"
+
+
+// Match calls a handler for every item whose whole key matches a pattern
+// (see pattern.Glob and pattern.Regexp) in key order. The pattern is fed
+// the prefixes shared by subtrees, so subtrees which cannot match are
+// skipped. It returns whether all matching items were reported.
+func (t *Dict[V]) Match(m *pattern.Matcher, handler func(Item[V]) bool) bool {
+	if t.Empty() {
+		return true
+	}
+	return t.match(t.root, t.root.first(), 0, m, m.Start(), handler)
+}
+
+// match visits the subtree at p whose leftmost key is first; s is the
+// pattern state after depth key bytes.
+func (t *Dict[V]) match(p Ref[V], first []byte, depth int, m *pattern.Matcher, s pattern.State, h func(Item[V]) bool) bool {
+	if p.node == nil {
+		if m.Match(m.Feed(s, p.Key[depth:])) {
+			return h(p.Item)
+		}
+		return true
+	}
+	// all keys of the subtree share the bytes of the leftmost one before the
+	// crit byte (passed down to the left child, found anew for the right one)
+	off := min(p.node.off, len(first))
+	if s = m.Feed(s, first[depth:off]); s.Dead() {
+		return true
+	}
+	right := &p.node.child[1]
+	return t.match(p.node.child[0], first, off, m, s, h) && t.match(*right, right.first(), off, m, s, h)
+}
@@ -0,0 +1,30 @@
+package dict
+
+import "testing"
+import "fmt"
+import "analyzers/lib/critbit/pattern"
+
+func Test_Match(t *testing.T) {
+	tr := NewDict[int]()
+	for i, s := range []string{
+		"user/1/settings", "user/1/profile", "user/22/settings", "user/3/x/settings", "users",
+	} {
+		tr.Set([]byte(s), i)
+	}
+	m, err := pattern.Glob("user/*/settings")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	var res []string
+	tr.Match(m, func(item Item[int]) bool {
+		res = append(res, fmt.Sprintf("%s=%d", item.Key, item.Val))
+		return true
+	})
+	expected := []string{"user/1/settings=0", "user/22/settings=2"}
+	if fmt.Sprint(res) != fmt.Sprint(expected) {
+		t.Errorf("got %q, expected %q", res, expected)
+	}
+	if m, _ = pattern.Regexp(`u.*s`); ! tr.Match(m, func(Item[int]) bool { return true }) {
+		t.Error("Match must report a complete walk")
+	}
+}
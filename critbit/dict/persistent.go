@@ -0,0 +1,203 @@
+package dict
+
+import "io"
+
+
+// PersistentDict is an immutable Dict. Set, Replace and Del return a new
+// version which shares all untouched nodes with the old one (path copying),
+// so every version stays valid and readable. Keeping a version around is
+// an O(1) snapshot, an update costs O(depth) node allocations.
+type PersistentDict[V any] struct {
+	t Dict[V]
+}
+
+func NewPersistentDict[V any](items ...Item[V]) *PersistentDict[V] {
+	pd := &PersistentDict[V]{}
+	InitDict(&pd.t, items...)
+	return pd
+}
+
+// Len returns the number of keys in the tree.
+func (pd *PersistentDict[V]) Len() int {
+	return pd.t.size
+}
+
+func (pd *PersistentDict[V]) Empty() bool {
+	return pd.t.Empty()
+}
+
+// Replace returns a new version where a previous value of the key is replaced
+// with the result of a func (see Dict.Replace).
+func (pd *PersistentDict[V]) Replace(key []byte, replace func(V, bool) V) *PersistentDict[V] {
+	var zero V
+
+	next := &PersistentDict[V]{t: Dict[V]{size: pd.t.size, root: pd.t.root}}
+	t := &next.t
+
+	// test for empty tree
+	if t.Empty() {
+		t.root.Item = Item[V]{key, replace(zero, false)}
+		t.size++
+		return next
+	}
+	// walk for best member
+	p := &t.root
+	for p.node != nil {
+		p = &p.node.child[p.node.dir(key)]
+	}
+	off, bit, pdir, differ := critbit(key, p.Key)
+
+	if ! differ {
+		// key exists - copy the whole path and replace the value
+		p = &t.root
+		for p.node != nil {
+			c := *p.node
+			p.node = &c
+			p = &c.child[c.dir(key)]
+		}
+		p.Val = replace(p.Val, true)
+		return next
+	}
+	// copy the path down to the insertion point
+	wp := &t.root
+	for wp.node != nil {
+		n := wp.node
+		if n.off > off || n.off == off && n.bit < bit {
+			break
+		}
+		c := *n
+		c.size++
+		wp.node = &c
+		wp = &c.child[c.dir(key)]
+	}
+	// insert new node
+	nn := &Node[V]{off:off, bit:bit}
+	nn.child[1-pdir].Item = Item[V]{key, replace(zero, false)}
+	nn.child[pdir] = *wp
+	nn.size = wp.count() + 1
+	wp.node = nn
+	wp.Item = Item[V]{}
+	t.size++
+
+	return next
+}
+
+// Set returns a new version where the key is associated with a given value.
+func (pd *PersistentDict[V]) Set(key []byte, val V) *PersistentDict[V] {
+	return pd.Replace(key, func(V, bool) V {return val})
+}
+
+// Del returns a new version without the key (or the same version if the key
+// is missing).
+func (pd *PersistentDict[V]) Del(key []byte) *PersistentDict[V] {
+	if _, ok := pd.t.Get(key); ! ok {
+		return pd
+	}
+	next := &PersistentDict[V]{t: Dict[V]{size: pd.t.size - 1, root: pd.t.root}}
+	t := &next.t
+
+	if t.root.node == nil {
+		t.root = Ref[V]{}
+		return next
+	}
+	// copy the path down to the parent of the leaf and unlink the leaf
+	p := &t.root
+	for {
+		n   := p.node
+		dir := n.dir(key)
+		if n.child[dir].node == nil {
+			*p = n.child[1-dir]
+			break
+		}
+		c := *n
+		c.size--
+		p.node = &c
+		p = &c.child[dir]
+	}
+	return next
+}
+
+// -- read-only access (see the Dict methods of the same name) --
+
+func (pd *PersistentDict[V]) Get(key []byte) (V, bool) {
+	return pd.t.Get(key)
+}
+
+func (pd *PersistentDict[V]) Iter(prefix []byte, handler func(Item[V]) bool) bool {
+	return pd.t.Iter(prefix, handler)
+}
+
+func (pd *PersistentDict[V]) IterReverse(prefix []byte, handler func(Item[V]) bool) bool {
+	return pd.t.IterReverse(prefix, handler)
+}
+
+func (pd *PersistentDict[V]) IterRange(lo, hi []byte, opts RangeOpts, handler func(Item[V]) bool) bool {
+	return pd.t.IterRange(lo, hi, opts, handler)
+}
+
+func (pd *PersistentDict[V]) Keys() [][]byte {
+	return pd.t.Keys()
+}
+
+func (pd *PersistentDict[V]) Items() ItemSlice[V] {
+	return pd.t.Items()
+}
+
+func (pd *PersistentDict[V]) Min(prefix []byte) (Item[V], bool) {
+	return pd.t.Min(prefix)
+}
+
+func (pd *PersistentDict[V]) Max(prefix []byte) (Item[V], bool) {
+	return pd.t.Max(prefix)
+}
+
+func (pd *PersistentDict[V]) Rank(key []byte) int {
+	return pd.t.Rank(key)
+}
+
+func (pd *PersistentDict[V]) Select(i int) (Item[V], bool) {
+	return pd.t.Select(i)
+}
+
+func (pd *PersistentDict[V]) CountPrefix(prefix []byte) int {
+	return pd.t.CountPrefix(prefix)
+}
+
+func (pd *PersistentDict[V]) CountRange(lo, hi []byte) int {
+	return pd.t.CountRange(lo, hi)
+}
+
+func (pd *PersistentDict[V]) LongestPrefixOf(key []byte) (Item[V], bool) {
+	return pd.t.LongestPrefixOf(key)
+}
+
+func (pd *PersistentDict[V]) PrefixesOf(key []byte, handler func(Item[V]) bool) bool {
+	return pd.t.PrefixesOf(key, handler)
+}
+
+// Stats collects statistics of this version (see Dict.Stats). Nodes shared
+// with other versions are counted in full.
+func (pd *PersistentDict[V]) Stats() Stats {
+	return pd.t.Stats()
+}
+
+// Dump writes a text rendering of this version (see Dict.Dump)
+func (pd *PersistentDict[V]) Dump(w io.Writer, opts DumpOpts) error {
+	return pd.t.Dump(w, opts)
+}
+
+// WriteDOT writes a Graphviz rendering of this version (see Dict.WriteDOT)
+func (pd *PersistentDict[V]) WriteDOT(w io.Writer) error {
+	return pd.t.WriteDOT(w)
+}
+
+// Cursor returns a cursor over this version (it is never invalidated).
+func (pd *PersistentDict[V]) Cursor() *Cursor[V] {
+	return pd.t.Cursor()
+}
+
+// Diff reports differences from another version (see Dict.Diff). Subtrees
+// shared by the versions are skipped, so it costs O(changes * depth).
+func (pd *PersistentDict[V]) Diff(other *PersistentDict[V], eq func(a, b V) bool, handler func(DiffEntry[V]) bool) bool {
+	return pd.t.Diff(&other.t, eq, handler)
+}
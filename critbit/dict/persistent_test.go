@@ -0,0 +1,82 @@
+package dict
+
+import "testing"
+import "bytes"
+
+func Test_PersistentVersions(t *testing.T) {
+	keys := []string{"bba", "aa", "bb", "aab", "ba", "aaa", "bbb", "ab"}
+
+	// build a chain of versions, one key at a time
+	versions := []*PersistentDict[int]{NewPersistentDict[int]()}
+	for i, s := range keys {
+		versions = append(versions, versions[i].Set([]byte(s), i))
+	}
+	for i, v := range versions {
+		if v.Len() != i {
+			t.Errorf("version %d: wrong length %d", i, v.Len())
+		}
+		for j, s := range keys {
+			val, ok := v.Get([]byte(s))
+			if ok != (j < i) || ok && val != j {
+				t.Errorf("version %d: wrong Get(%q) result (%v, %v)", i, s, val, ok)
+			}
+		}
+		checkSizes(t, &v.t.root)
+	}
+
+	// updates and deletions leave the old versions intact
+	last := versions[len(versions)-1]
+	upd  := last.Set([]byte("aa"), 100).Del([]byte("bb")).Del([]byte("zzz"))
+	if v, _ := last.Get([]byte("aa")); v != 1 {
+		t.Errorf("old version changed: aa=%v", v)
+	}
+	if _, ok := last.Get([]byte("bb")); ! ok || last.Len() != 8 {
+		t.Error("old version lost a key")
+	}
+	if v, _ := upd.Get([]byte("aa")); v != 100 {
+		t.Errorf("new version: aa=%v, expected 100", v)
+	}
+	if _, ok := upd.Get([]byte("bb")); ok || upd.Len() != 7 {
+		t.Error("new version still has a deleted key")
+	}
+	if item, _ := upd.Select(5); string(item.Key) != "bba" {
+		t.Errorf("new version: Select(5) -> %q", item.Key)
+	}
+	checkSizes(t, &last.t.root)
+	checkSizes(t, &upd.t.root)
+
+	// deleting a missing key returns the same version
+	if upd.Del([]byte("missing")) != upd {
+		t.Error("Del of a missing key must not create a version")
+	}
+	// delete everything
+	v := upd
+	for _, key := range upd.Keys() {
+		v = v.Del(key)
+	}
+	if ! v.Empty() || upd.Len() != 7 {
+		t.Errorf("wrong lengths after deletion: %d, %d", v.Len(), upd.Len())
+	}
+}
+
+func Test_PersistentSharing(t *testing.T) {
+	a := NewPersistentDict[int]()
+	for i := 0; i < 64; i++ {
+		a = a.Set([]byte{'k', byte(i)}, i)
+	}
+	b := a.Set([]byte{'k', 63}, -1)
+
+	// only the path to the updated leaf is copied
+	if a.t.root.node == b.t.root.node {
+		t.Error("the root must be copied")
+	}
+	if a.t.root.node.child[0].node != b.t.root.node.child[0].node {
+		t.Error("the untouched subtree must be shared")
+	}
+	var ka, kb [][]byte
+	a.Iter(nil, func(item Item[int]) bool { ka = append(ka, item.Key); return true })
+	b.Iter(nil, func(item Item[int]) bool { kb = append(kb, item.Key); return true })
+	if ! testKeysEq(ka, kb) || ! bytes.Equal(ka[63], []byte{'k', 63}) {
+		t.Errorf("versions have different keys: %q vs %q", ka, kb)
+	}
+}
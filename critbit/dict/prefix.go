@@ -0,0 +1,59 @@
+package dict
+
+
+// LongestPrefixOf returns the item with the longest stored key that is a
+// prefix of the key (or the key itself).
+func (t *Dict[V]) LongestPrefixOf(key []byte) (item Item[V], ok bool) {
+	t.PrefixesOf(key, func(it Item[V]) bool {
+		item, ok = it, true
+		return true
+	})
+	return
+}
+
+// PrefixesOf calls a handler for all stored keys that are prefixes of the key
+// (including the key itself) from the shortest to the longest.
+// It returns whether all such keys were reported.
+// The handler can continue the process by returning true or abort with false.
+func (t *Dict[V]) PrefixesOf(key []byte, handler func(Item[V]) bool) bool {
+	// test for empty tree
+	if t.Empty() {
+		return true
+	}
+	// Walk for best member. A stored prefix of length L goes left at every
+	// node with off >= L, so the only candidate hidden from the path is the
+	// leftmost leaf of a left sibling (and only if the key turns right).
+	p := &t.root
+	for p.node != nil {
+		n   := p.node
+		dir := n.dir(key)
+		if dir == 1 {
+			c := &n.child[0]
+			for c.node != nil {
+				c = &c.node.child[0]
+			}
+			if isPrefix(c.Key, key) && ! handler(c.Item) {
+				return false
+			}
+		}
+		// try next node
+		p = &n.child[dir]
+	}
+	if isPrefix(p.Key, key) {
+		return handler(p.Item)
+	}
+	return true
+}
+
+// isPrefix tells whether the prefix is a prefix of the key
+func isPrefix(prefix, key []byte) bool {
+	if len(prefix) > len(key) {
+		return false
+	}
+	for i, b := range prefix {
+		if key[i] != b {
+			return false
+		}
+	}
+	return true
+}
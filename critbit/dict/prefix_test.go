@@ -0,0 +1,55 @@
+package dict
+
+import "testing"
+
+func Test_PrefixesOf(t *testing.T) {
+	tr := NewDict[int]()
+	for _, s := range []string{"/", "/api", "/api/v1", "/api/v1/users", "/apix", "/b", "/api/v2"} {
+		tr.Set([]byte(s), len(s))
+	}
+	tests := []struct {
+		key  string
+		keys []string
+	}{
+		{"/api/v1/users/42", []string{"/", "/api", "/api/v1", "/api/v1/users"}},
+		{"/api/v1",          []string{"/", "/api", "/api/v1"}},
+		{"/api/v3",          []string{"/", "/api"}},
+		{"/apixyz",          []string{"/", "/api", "/apix"}},
+		{"/c",               []string{"/"}},
+		{"x",                nil},
+		{"",                 nil},
+	}
+	for i, test := range tests {
+		var got []string
+		tr.PrefixesOf([]byte(test.key), func(item Item[int]) bool {
+			if item.Val != len(item.Key) {
+				t.Errorf("test %d: wrong value of %q: %v", i, item.Key, item.Val)
+			}
+			got = append(got, string(item.Key))
+			return true
+		})
+		if len(got) != len(test.keys) {
+			t.Errorf("test %d: PrefixesOf(%q) -> %q, expected %q", i, test.key, got, test.keys)
+			continue
+		}
+		for j := range got {
+			if got[j] != test.keys[j] {
+				t.Errorf("test %d: PrefixesOf(%q) -> %q, expected %q", i, test.key, got, test.keys)
+				break
+			}
+		}
+		var longest string
+		if len(test.keys) > 0 {
+			longest = test.keys[len(test.keys)-1]
+		}
+		if item, ok := tr.LongestPrefixOf([]byte(test.key)); string(item.Key) != longest || ok != (longest != "") {
+			t.Errorf("test %d: LongestPrefixOf(%q) -> (%q, %v), expected %q", i, test.key, item.Key, ok, longest)
+		}
+	}
+
+	// abort after the shortest prefix
+	n := 0
+	if tr.PrefixesOf([]byte("/api/v1"), func(Item[int]) bool { n++; return false }) || n != 1 {
+		t.Errorf("PrefixesOf must stop when the handler returns false (%d calls)", n)
+	}
+}
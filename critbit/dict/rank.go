@@ -0,0 +1,75 @@
+package dict
+
+
+// Rank returns the number of keys less than the key.
+func (t *Dict[V]) Rank(key []byte) (rank int) {
+	// test for empty tree
+	if t.Empty() {
+		return
+	}
+	// walk for best member
+	p := &t.root
+	for p.node != nil {
+		p = &p.node.child[p.node.dir(key)]
+	}
+	off, bit, pdir, differ := critbit(key, p.Key)
+
+	// walk down to the insertion point counting lesser siblings
+	wp := &t.root
+	for wp.node != nil {
+		n := wp.node
+		if differ && (n.off > off || n.off == off && n.bit < bit) {
+			break
+		}
+		dir := n.dir(key)
+		if dir == 1 {
+			rank += n.child[0].count()
+		}
+		wp = &n.child[dir]
+	}
+	// the subtree at the insertion point is either all greater or all lesser
+	if differ && pdir == 0 {
+		rank += wp.count()
+	}
+	return
+}
+
+// Select returns the item at a given position in key order (starting from 0).
+func (t *Dict[V]) Select(i int) (item Item[V], ok bool) {
+	if i < 0 || i >= t.size {
+		return
+	}
+	p := &t.root
+	for p.node != nil {
+		if left := p.node.child[0].count(); i >= left {
+			i -= left
+			p = &p.node.child[1]
+		} else {
+			p = &p.node.child[0]
+		}
+	}
+	return p.Item, true
+}
+
+// CountPrefix returns the number of keys with a given prefix.
+func (t *Dict[V]) CountPrefix(prefix []byte) int {
+	if top, _ := t.findTop(prefix); top != nil {
+		return top.count()
+	}
+	return 0
+}
+
+// CountRange returns the number of keys in [lo, hi). A nil bound is unbounded.
+func (t *Dict[V]) CountRange(lo, hi []byte) (n int) {
+	n = t.size
+	if hi != nil {
+		n = t.Rank(hi)
+	}
+	if lo != nil {
+		n -= t.Rank(lo)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return
+}
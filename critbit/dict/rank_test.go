@@ -0,0 +1,94 @@
+package dict
+
+import "testing"
+import "bytes"
+
+// checkSizes verifies subtree sizes of all nodes
+func checkSizes[V any](t *testing.T, ref *Ref[V]) int {
+	if ref.node == nil {
+		return 1
+	}
+	n := checkSizes(t, &ref.node.child[0]) + checkSizes(t, &ref.node.child[1])
+	if n != ref.node.size {
+		t.Errorf("wrong size of %v: expected %d, got %d", ref, n, ref.node.size)
+	}
+	return n
+}
+
+func Test_RankSelect(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	keys := tr.Keys()
+	checkSizes(t, &tr.root)
+
+	for i, key := range keys {
+		if r := tr.Rank(key); r != i {
+			t.Errorf("Rank(%q) -> %d, expected %d", key, r, i)
+		}
+		if item, ok := tr.Select(i); ! ok || ! bytes.Equal(item.Key, key) {
+			t.Errorf("Select(%d) -> %q, expected %q", i, item.Key, key)
+		}
+	}
+	tests := []struct {
+		key  string
+		rank int
+	}{
+		{"", 0}, {"a", 0}, {"aaaa", 2}, {"aac", 3}, {"b", 4}, {"bab", 5}, {"c", 8},
+	}
+	for _, test := range tests {
+		if r := tr.Rank([]byte(test.key)); r != test.rank {
+			t.Errorf("Rank(%q) -> %d, expected %d", test.key, r, test.rank)
+		}
+	}
+	if _, ok := tr.Select(-1); ok {
+		t.Error("Select(-1) must fail")
+	}
+	if _, ok := tr.Select(len(keys)); ok {
+		t.Errorf("Select(%d) must fail", len(keys))
+	}
+}
+
+func Test_CountPrefixRange(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	prefixes := []struct {
+		prefix string
+		count  int
+	}{
+		{"", 8}, {"a", 4}, {"aa", 3}, {"aab", 1}, {"bb", 3}, {"c", 0}, {"aaaa", 0},
+	}
+	for _, test := range prefixes {
+		if n := tr.CountPrefix([]byte(test.prefix)); n != test.count {
+			t.Errorf("CountPrefix(%q) -> %d, expected %d", test.prefix, n, test.count)
+		}
+	}
+	ranges := []struct {
+		lo, hi []byte
+		count  int
+	}{
+		{nil, nil, 8},
+		{[]byte("aab"), []byte("bb"), 3},
+		{[]byte("b"), nil, 4},
+		{nil, []byte("ab"), 3},
+		{[]byte("bb"), []byte("aa"), 0},
+	}
+	for _, test := range ranges {
+		if n := tr.CountRange(test.lo, test.hi); n != test.count {
+			t.Errorf("CountRange(%q, %q) -> %d, expected %d", test.lo, test.hi, n, test.count)
+		}
+	}
+}
+
+func Test_SizesAfterDelete(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	tr.Del([]byte("aab"))
+	tr.Del([]byte("missing"))
+	tr.PopMax([]byte("b"))
+	tr.PopMin(nil)
+	checkSizes(t, &tr.root)
+
+	if n := tr.CountPrefix(nil); n != tr.Len() || n != 5 {
+		t.Errorf("CountPrefix(nil) -> %d, expected %d", n, tr.Len())
+	}
+	if item, _ := tr.Select(2); string(item.Key) != "ba" {
+		t.Errorf("Select(2) -> %q, expected \"ba\"", item.Key)
+	}
+}
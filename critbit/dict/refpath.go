@@ -1,19 +1,19 @@
 package dict
 
 
-type RefPath struct {
-	Refs, LastRefs []*Ref
+type RefPath[V any] struct {
+	Refs, LastRefs []*Ref[V]
 	Dirs, LastDirs []uint64  // bitmap
 }
 
-func NewRefPath() *RefPath {
-	return &RefPath{
-		Refs : make([]*Ref, 0, 21),
+func NewRefPath[V any]() *RefPath[V] {
+	return &RefPath[V]{
+		Refs : make([]*Ref[V], 0, 21),
 		Dirs : make([]uint64, 1),
 	}
 }
 
-func (path *RefPath) GetLeaf() (leaf *Ref) {
+func (path *RefPath[V]) GetLeaf() (leaf *Ref[V]) {
 	if path == nil {
 		return
 	}
@@ -27,7 +27,7 @@ func (path *RefPath) GetLeaf() (leaf *Ref) {
 	}
 	return
 }
-func (path *RefPath) Append(ref *Ref, dir byte) {
+func (path *RefPath[V]) Append(ref *Ref[V], dir byte) {
 	//fmt.Printf("Append(%v, %v)\n", ref, dir)
 	idx := uint64(len(path.Refs))
 	off := idx >> 6
@@ -40,7 +40,7 @@ func (path *RefPath) Append(ref *Ref, dir byte) {
 		path.Dirs[off] |= uint64(1) << (idx & 0x3F)  // 3F == 0011 1111
 	}
 }
-func (path *RefPath) Pop() (ref *Ref, dir byte) {
+func (path *RefPath[V]) Pop() (ref *Ref[V], dir byte) {
 	num := len(path.Refs)
 	if num == 0 {
 		//fmt.Printf("Pop() -> %v, %v\n", ref, dir)
@@ -56,9 +56,9 @@ func (path *RefPath) Pop() (ref *Ref, dir byte) {
 	//fmt.Printf("Pop() -> %v, %v\n", ref, dir)
 	return
 }
-func (path *RefPath) Copy() *RefPath {
-	new := RefPath{
-		Refs : make([]*Ref,   len(path.Refs), cap(path.Refs)),
+func (path *RefPath[V]) Copy() *RefPath[V] {
+	new := RefPath[V]{
+		Refs : make([]*Ref[V],   len(path.Refs), cap(path.Refs)),
 		Dirs : make([]uint64, len(path.Dirs), cap(path.Dirs)),
 	}
 	// copy data
@@ -67,7 +67,7 @@ func (path *RefPath) Copy() *RefPath {
 
 	return &new
 }
-func (path *RefPath) Backup() {
+func (path *RefPath[V]) Backup() {
 	// make sure the last-ref slice has enough room
 	n_refs := len(path.Refs)
 	if l := len(path.LastRefs); l < n_refs {
@@ -75,7 +75,7 @@ func (path *RefPath) Backup() {
 			C := int(float64(n_refs) * 1.5)
 			if C < 21 {C = 21}
 			//fmt.Printf("Backup(): allocating a larger Refs block: %v(%v) -> %v(%v)\n", l, c, n_refs, C)
-			path.LastRefs = make([]*Ref, n_refs, C)  // alloc a larger block
+			path.LastRefs = make([]*Ref[V], n_refs, C)  // alloc a larger block
 		}
 	}
 	path.LastRefs = path.LastRefs[:n_refs]  // set the upper bound
@@ -95,7 +95,7 @@ func (path *RefPath) Backup() {
 	copy(path.LastRefs, path.Refs)
 	copy(path.LastDirs, path.Dirs)
 }
-func (path *RefPath) Revert() *Ref {
+func (path *RefPath[V]) Revert() *Ref[V] {
 	path.Refs, path.LastRefs = path.LastRefs, path.Refs
 	path.Dirs, path.LastDirs = path.LastDirs, path.Dirs
 	if path.LastRefs != nil {
@@ -104,7 +104,7 @@ func (path *RefPath) Revert() *Ref {
 	}
 	return path.GetLeaf()
 }
-func (path *RefPath) TrackNext() (ref *Ref) {
+func (path *RefPath[V]) TrackNext() (ref *Ref[V]) {
 	// discard current leaf
 	_, dir := path.Pop()
 	// keep ascending while dir is 1 (we were in a right branch)
@@ -127,7 +127,7 @@ func (path *RefPath) TrackNext() (ref *Ref) {
 	//fmt.Printf("TrackNext() -> %v\n", ref)
 	return
 }
-func (path *RefPath) TrackPrev() (ref *Ref) {
+func (path *RefPath[V]) TrackPrev() (ref *Ref[V]) {
 	// discard current leaf
 	_, dir := path.Pop()
 	// keep ascending while dir is 0 (we were in a left branch)
@@ -0,0 +1,42 @@
+package dict
+
+import "iter"
+
+
+// All returns an iterator over all keys and values in key order.
+func (t *Dict[V]) All() iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		t.Iter(nil, func(item Item[V]) bool {
+			return yield(item.Key, item.Val)
+		})
+	}
+}
+
+// Prefix returns an iterator over all keys with a given prefix and their
+// values in key order.
+func (t *Dict[V]) Prefix(prefix []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		t.Iter(prefix, func(item Item[V]) bool {
+			return yield(item.Key, item.Val)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in reverse key order.
+func (t *Dict[V]) Backward() iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		t.IterReverse(nil, func(item Item[V]) bool {
+			return yield(item.Key, item.Val)
+		})
+	}
+}
+
+// Range returns an iterator over keys in [lo, hi) and their values in key
+// order. A nil bound is unbounded (see IterRange for other bound kinds).
+func (t *Dict[V]) Range(lo, hi []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		t.IterRange(lo, hi, RangeOpts{HiExclusive: true}, func(item Item[V]) bool {
+			return yield(item.Key, item.Val)
+		})
+	}
+}
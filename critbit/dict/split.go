@@ -0,0 +1,138 @@
+package dict
+
+import "errors"
+
+
+var ErrOverlap = errors.New("dict: key ranges overlap")
+
+// SplitAt divides the dict at a key into two: lo gets all keys less than
+// the key and hi gets the rest. The nodes are relinked, not copied, so the
+// dict is left empty. Each dict gets its own key arena in the same mode
+// (in the arena.Debug mode the keys are handed over one by one).
+func (t *Dict[V]) SplitAt(key []byte) (lo, hi *Dict[V]) {
+	lo = &Dict[V]{codec: t.codec, arena: t.arena.Fork()}
+	hi = &Dict[V]{codec: t.codec, arena: t.arena.Fork()}
+	if t.Empty() {
+		return
+	}
+	// walk for best member
+	p := &t.root
+	for p.node != nil {
+		p = &p.node.child[p.node.dir(key)]
+	}
+	off, bit, pdir, differ := critbit(key, p.Key)
+
+	// walk down to the insertion point splitting the path nodes:
+	// the ones turning right keep lesser keys on the left and vice versa
+	var lo_path, hi_path []*Node[V]
+	wp := t.root
+	for wp.node != nil {
+		n := wp.node
+		if differ && (n.off > off || n.off == off && n.bit < bit) {
+			break
+		}
+		dir := n.dir(key)
+		if dir == 1 {
+			lo_path = append(lo_path, n)
+		} else {
+			hi_path = append(hi_path, n)
+		}
+		wp = n.child[dir]
+	}
+	// the subtree at the insertion point is either all greater or all lesser
+	var lo_rest, hi_rest Ref[V]
+	if differ && pdir == 0 {
+		lo_rest = wp
+	} else {
+		hi_rest = wp
+	}
+	lo.root = chain(lo_path, 1, lo_rest)
+	hi.root = chain(hi_path, 0, hi_rest)
+	if ! lo.Empty() {
+		lo.size = lo.root.count()
+	}
+	hi.size = t.size - lo.size
+	t.arena.Transfer(&lo.arena, lo.moveAllKeys)
+	t.arena.Transfer(&hi.arena, hi.moveAllKeys)
+
+	*t = Dict[V]{gen: t.gen + 1, codec: t.codec, arena: t.arena.Fork()}
+	return
+}
+
+// chain links path nodes top-down through their dir children ending with
+// the rest subtree. Nodes left with a single child are dropped.
+func chain[V any](path []*Node[V], dir byte, rest Ref[V]) Ref[V] {
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		if rest.node == nil && len(rest.Key) == 0 {
+			rest = n.child[1-dir]
+			continue
+		}
+		n.child[dir] = rest
+		n.size = n.child[0].count() + n.child[1].count()
+		rest = Ref[V]{node: n}
+	}
+	return rest
+}
+
+// Join moves all items of another dict into this one. The key ranges must
+// not overlap (but either dict may hold the lesser keys), otherwise it
+// returns ErrOverlap and leaves both dicts intact. The nodes are relinked,
+// not copied, so the other dict is left empty. Its keys are handed over to
+// the key arena of this dict (and copied if this one is in the arena.Copy
+// mode and the other is not).
+func (t *Dict[V]) Join(other *Dict[V]) error {
+	if other == nil || other == t || other.Empty() {
+		return nil
+	}
+	if t.Empty() {
+		other.arena.Transfer(&t.arena, other.moveAllKeys)
+		t.root, t.size = other.root, other.size
+		t.gen++
+		*other = Dict[V]{gen: other.gen + 1, codec: other.codec, arena: other.arena.Fork()}
+		return nil
+	}
+	a, b := t, other
+	tmax, _ := t.edge(nil, 1)
+	omin, _ := other.edge(nil, 0)
+	off, bit, odir, differ := critbit(omin.Key, tmax.Key)
+	if ! differ || odir == 1 {
+		// try the other way around
+		a, b = other, t
+		omax, _ := other.edge(nil, 1)
+		tmin, _ := t.edge(nil, 0)
+		if off, bit, odir, differ = critbit(tmin.Key, omax.Key); ! differ || odir == 1 {
+			return ErrOverlap
+		}
+	}
+	other.arena.Transfer(&t.arena, other.moveAllKeys)
+	t.root = join(a.root, b.root, off, bit)
+	t.size = a.size + b.size
+	t.gen++
+	*other = Dict[V]{gen: other.gen + 1, codec: other.codec, arena: other.arena.Fork()}
+	return nil
+}
+
+// join merges the right spine of a with the left spine of b, where all keys
+// of a are less than all keys of b and the greatest key of a differs from
+// the least key of b at the given crit bit. Spine nodes above the crit bit
+// are kept (shallower first) and a new node joins the rest.
+func join[V any](a, b Ref[V], off int, bit byte) Ref[V] {
+	above := func(n *Node[V]) bool {
+		return n != nil && (n.off < off || n.off == off && n.bit > bit)
+	}
+	na, nb := a.node, b.node
+	switch {
+	case above(na) && (! above(nb) || na.off < nb.off || na.off == nb.off && na.bit > nb.bit):
+		na.size += b.count()
+		na.child[1] = join(na.child[1], b, off, bit)
+		return a
+	case above(nb):
+		nb.size += a.count()
+		nb.child[0] = join(a, nb.child[0], off, bit)
+		return b
+	}
+	nn := &Node[V]{off:off, bit:bit, size:a.count() + b.count()}
+	nn.child[0], nn.child[1] = a, b
+	return Ref[V]{node: nn}
+}
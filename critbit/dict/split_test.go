@@ -0,0 +1,166 @@
+package dict
+
+import "testing"
+import "bytes"
+import "errors"
+import "slices"
+import "analyzers/lib/critbit/arena"
+
+func Test_SplitAt(t *testing.T) {
+	split_keys := append(slices.Clone(testDictKeys), "c")
+	all := testDict(split_keys...).Keys()
+	for _, s := range []string{"", "a", "aa", "aaa", "aaaa", "aab", "aac", "ab", "b", "ba", "bab", "bba", "bbc", "c", "ca", "d"} {
+		key := []byte(s)
+		tr := testDict(split_keys...)
+		lo, hi := tr.SplitAt(key)
+
+		var lo_keys, hi_keys [][]byte
+		for _, k := range all {
+			if bytes.Compare(k, key) < 0 {
+				lo_keys = append(lo_keys, k)
+			} else {
+				hi_keys = append(hi_keys, k)
+			}
+		}
+		if keys := lo.Keys(); ! testKeysEq(keys, lo_keys) || lo.Len() != len(lo_keys) {
+			t.Errorf("SplitAt(%q): lo = %q (len %d), expected %q", key, keys, lo.Len(), lo_keys)
+		}
+		if keys := hi.Keys(); ! testKeysEq(keys, hi_keys) || hi.Len() != len(hi_keys) {
+			t.Errorf("SplitAt(%q): hi = %q (len %d), expected %q", key, keys, hi.Len(), hi_keys)
+		}
+		checkSizes(t, &lo.root)
+		checkSizes(t, &hi.root)
+		if ! tr.Empty() || tr.Len() != 0 {
+			t.Errorf("SplitAt(%q): the dict must be left empty", key)
+		}
+
+		// join back in both orders
+		if err := hi.Join(lo); err != nil {
+			t.Errorf("Join after SplitAt(%q) failed: %v", key, err)
+		}
+		if keys := hi.Keys(); ! testKeysEq(keys, all) || hi.Len() != len(all) || ! lo.Empty() {
+			t.Errorf("Join after SplitAt(%q) -> %q (len %d)", key, keys, hi.Len())
+		}
+		checkSizes(t, &hi.root)
+		for i, k := range all {
+			if hi.Rank(k) != i {
+				t.Errorf("Join after SplitAt(%q): wrong rank of %q", key, k)
+			}
+		}
+		lo, hi = hi.SplitAt(key)
+		if err := lo.Join(hi); err != nil || ! testKeysEq(lo.Keys(), all) {
+			t.Errorf("Join after SplitAt(%q) -> %q, %v", key, lo.Keys(), err)
+		}
+		checkSizes(t, &lo.root)
+	}
+}
+
+func Test_Join(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		err  bool
+	}{
+		{[]string{"a", "ba"}, []string{"bb"}, false},
+		{[]string{"bb"}, []string{"a", "ba"}, false},
+		{[]string{"aa", "ab"}, []string{"aaa"}, true},
+		{[]string{"a", "c"}, []string{"b"}, true},
+		{[]string{"a"}, []string{"a"}, true},
+		{[]string{"x", "xa"}, []string{"xb", "y"}, false},
+		{[]string{"abc"}, nil, false},
+		{nil, []string{"abc"}, false},
+	}
+	for i, test := range tests {
+		a, b := NewDict[int](), NewDict[int]()
+		for _, s := range test.a {
+			a.Set([]byte(s), 1)
+		}
+		for _, s := range test.b {
+			b.Set([]byte(s), 2)
+		}
+		cur := a.Cursor()
+		cur.First()
+		err := a.Join(b)
+		if test.err {
+			if ! errors.Is(err, ErrOverlap) || a.Len() != len(test.a) || b.Len() != len(test.b) {
+				t.Errorf("test %d: expected ErrOverlap and intact dicts, got %v", i, err)
+			}
+			continue
+		}
+		var expected [][]byte
+		for _, s := range append(append([]string{}, test.a...), test.b...) {
+			expected = append(expected, []byte(s))
+		}
+		slices.SortFunc(expected, bytes.Compare)
+		if err != nil || ! testKeysEq(a.Keys(), expected) || a.Len() != len(expected) || ! b.Empty() {
+			t.Errorf("test %d: Join -> %q, %v", i, a.Keys(), err)
+		}
+		checkSizes(t, &a.root)
+		if len(test.b) > 0 && cur.Next() {
+			t.Errorf("test %d: Join must invalidate cursors", i)
+		}
+	}
+}
+
+func Test_SplitJoinKeyArenas(t *testing.T) {
+	strs := func(keys [][]byte) []string {
+		res := []string{}
+		for _, k := range keys {
+			res = append(res, string(k))
+		}
+		return res
+	}
+	// every dict compacts its own keys only
+	tr := NewDict[int]().SetKeyMode(arena.Copy)
+	for i, s := range []string{"aa", "ab", "ba", "bb"} {
+		tr.Set([]byte(s), i)
+	}
+	lo, hi := tr.SplitAt([]byte("b"))
+	tr.Set([]byte("zz"), 0)
+	hi.Set([]byte("bc"), 4)
+	for _, d := range []*Dict[int]{lo, hi, tr, lo} {
+		d.Compact()
+	}
+	if keys := strs(lo.Keys()); ! slices.Equal(keys, []string{"aa", "ab"}) {
+		t.Errorf("lo keys after Compact: %q", keys)
+	}
+	if keys := strs(hi.Keys()); ! slices.Equal(keys, []string{"ba", "bb", "bc"}) {
+		t.Errorf("hi keys after Compact: %q", keys)
+	}
+	if lo.arena.Mode() != arena.Copy || hi.arena.Mode() != arena.Copy || tr.arena.Mode() != arena.Copy {
+		t.Error("SplitAt must keep the key mode")
+	}
+
+	// debug snapshots follow the keys
+	buf := []byte("aabb")
+	tr = NewDict[int]().SetKeyMode(arena.Debug)
+	tr.Set(buf[0:2], 1)
+	tr.Set(buf[2:4], 2)
+	lo, hi = tr.SplitAt([]byte("b"))
+	buf[1] = 'x'
+	if lo.CheckKeys() == nil || hi.CheckKeys() != nil {
+		t.Error("CheckKeys after SplitAt must detect a modified key of lo only")
+	}
+	buf[1] = 'a'
+	if err := hi.Join(lo); err != nil || hi.CheckKeys() != nil {
+		t.Errorf("unexpected error: %v, %v", err, hi.CheckKeys())
+	}
+	buf[1] = 'x'
+	if hi.CheckKeys() == nil {
+		t.Error("CheckKeys after Join must detect a modified key moved in")
+	}
+
+	// joining borrowed keys into the Copy mode copies them
+	copy(buf, "aabb")
+	tr = NewDict[int]().SetKeyMode(arena.Copy)
+	tr.Set([]byte("cc"), 3)
+	other := NewDict[int]()
+	other.Set(buf[0:2], 1)
+	other.Set(buf[2:4], 2)
+	if err := tr.Join(other); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	copy(buf, "xxxx")
+	if keys := strs(tr.Keys()); ! slices.Equal(keys, []string{"aa", "bb", "cc"}) {
+		t.Errorf("Join must copy borrowed keys, got %q", keys)
+	}
+}
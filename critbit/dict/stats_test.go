@@ -0,0 +1,32 @@
+package dict
+
+import "testing"
+import "slices"
+import "analyzers/lib/critbit/arena"
+
+func Test_Stats(t *testing.T) {
+	s := NewDict[int]().Stats()
+	if s.Nodes != 0 || s.Leaves != 0 || len(s.Depths) != 0 || s.KeyBytes != 0 || s.HeapBytes() != s.RefBytes {
+		t.Errorf("wrong stats of an empty dict: %+v", s)
+	}
+
+	// 'a' differs from 'b' and 'c' in the 0x02 bit, 'b' and 'c' differ in the 0x01 bit
+	tr := NewDict(Item[int]{[]byte("a"), 1}, Item[int]{[]byte("b"), 2}, Item[int]{[]byte("c"), 3})
+	s = tr.Stats()
+	if s.Nodes != 2 || s.Leaves != 3 || s.KeyBytes != 3 {
+		t.Errorf("wrong counts: %+v", s)
+	}
+	if ! slices.Equal(s.Depths, []int{0, 1, 2}) || s.MaxDepth != 2 || s.AvgDepth != 5.0/3 {
+		t.Errorf("wrong depths: %+v", s)
+	}
+	if s.NodeBytes <= 0 || s.RefBytes <= 0 || s.KeyHeapBytes < s.KeyBytes {
+		t.Errorf("wrong memory estimates: %+v", s)
+	}
+
+	tr = NewDict[int]().SetKeyMode(arena.Copy)
+	tr.Set([]byte("abc"), 1)
+	tr.Set([]byte("abd"), 2)
+	if s := tr.Stats(); s.KeyBytes != 6 || s.KeyHeapBytes != tr.arena.Size() {
+		t.Errorf("wrong key memory: %+v", s)
+	}
+}
@@ -0,0 +1,105 @@
+package dict
+
+import "testing"
+import "bytes"
+import "strings"
+import "encoding/json"
+import "analyzers/lib/critbit/arena"
+
+type textVal struct {
+	N int
+	S string `json:",omitempty"`
+}
+
+func testItemsEq[V comparable](a, b []Item[V]) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if ! bytes.Equal(a[i].Key, b[i].Key) || a[i].Val != b[i].Val {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_JSON(t *testing.T) {
+	tr := NewDict[textVal]()
+	for i, s := range []string{"b", "a\\b", "a\tb", "\xff\x01", "ключ", "a\"b"} {
+		tr.Set([]byte(s), textVal{i, string(rune('A' + i))})
+	}
+	data, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"a\\x09b":{"N":2,"S":"C"},"a\"b":{"N":5,"S":"F"},"a\\\\b":{"N":1,"S":"B"},` +
+		`"b":{"N":0,"S":"A"},"ключ":{"N":4,"S":"E"},"\\xff\\x01":{"N":3,"S":"D"}}`
+	if string(data) != expected {
+		t.Errorf("wrong JSON:\n  got %s\n  exp %s", data, expected)
+	}
+	res := NewDict[textVal]()
+	res.Set([]byte("stale"), textVal{})
+	if err := json.Unmarshal(data, res); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if ! testItemsEq(res.Items(), tr.Items()) {
+		t.Errorf("round-trip failed: %v", res.Items())
+	}
+	checkSizes(t, &res.root)
+
+	for _, bad := range []string{`{"":{}}`, `{"\\x":{}}`, `{"a":1}`, `[]`,
+		// non-canonical escapes would let two keys decode to the same one
+		`{"\\x41":{},"A":{"N":2}}`, `{"\\x0A":{}}`, `{"\\xc3\\xa9":{}}`} {
+		if err := json.Unmarshal([]byte(bad), res); err == nil {
+			t.Errorf("Unmarshal(%s) must fail", bad)
+		}
+	}
+}
+
+func Test_TSV(t *testing.T) {
+	tr := NewDict[string]()
+	for _, s := range []string{"b", "a\tb", "\xff\x01"} {
+		tr.Set([]byte(s), strings.ToValidUTF8(s, "?"))
+	}
+	var buf bytes.Buffer
+	if err := tr.WriteTSV(&buf); err != nil {
+		t.Fatalf("WriteTSV failed: %v", err)
+	}
+	expected := "a\\x09b\t\"a\\tb\"\nb\t\"b\"\n\\xff\\x01\t\"?\\u0001\"\n"
+	if buf.String() != expected {
+		t.Errorf("wrong TSV:\n  got %q\n  exp %q", buf.String(), expected)
+	}
+	res := NewDict[textVal]()
+	if err := res.ReadTSV(strings.NewReader("x\t{\"N\":1}\ny\t{\"N\":2,\"S\":\"\\t\"}")); err != nil {
+		t.Fatalf("ReadTSV failed: %v", err)
+	}
+	items := []Item[textVal]{{[]byte("x"), textVal{1, ""}}, {[]byte("y"), textVal{2, "\t"}}}
+	if ! testItemsEq(res.Items(), items) {
+		t.Errorf("ReadTSV -> %v", res.Items())
+	}
+	cur := res.Cursor()
+	cur.First()
+
+	for _, bad := range []string{"a\n", "a\t{\n", "\t{}\n", "a\\q\t{}\n", "\\x61\t{}\n"} {
+		if err := res.ReadTSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadTSV(%q) must fail", bad)
+		}
+	}
+	if res.Len() != 2 || ! cur.Next() {
+		t.Errorf("failed ReadTSV must keep the dict intact, got %q", res.Keys())
+	}
+	if res.ReadTSV(strings.NewReader("")) != nil || ! res.Empty() || cur.Next() {
+		t.Error("ReadTSV must replace the content and invalidate cursors")
+	}
+
+	// the key arena starts afresh in the same mode
+	res = NewDict[textVal]().SetKeyMode(arena.Copy)
+	res.Set([]byte("old"), textVal{})
+	if res.ReadTSV(strings.NewReader("")) != nil || res.arena.Size() != 0 || res.arena.Mode() != arena.Copy {
+		t.Errorf("ReadTSV must reset the key arena, size %d", res.arena.Size())
+	}
+	res.Set([]byte("old"), textVal{})
+	if json.Unmarshal([]byte(`{}`), res) != nil || res.arena.Size() != 0 || res.arena.Mode() != arena.Copy {
+		t.Errorf("UnmarshalJSON must reset the key arena, size %d", res.arena.Size())
+	}
+}
@@ -0,0 +1,111 @@
+package dict
+
+import "reflect"
+
+
+// Update applies a func to the current value of a key (exists is false and
+// old is a zero value if the key is missing). The result is stored unless
+// keep is false, in which case the key is deleted (or not inserted at all).
+// Returns the value stored and whether the key exists afterwards (a zero
+// value and false if it does not).
+// The tree is descended once, the func must not modify the dict.
+func (t *Dict[V]) Update(key []byte, update func(old V, exists bool) (new V, keep bool)) (val V, ok bool) {
+	var zero V
+
+	// test for empty tree
+	if t.Empty() {
+		if val, ok = update(zero, false); ! ok {
+			return zero, false
+		}
+		t.root.Item = Item[V]{t.arena.Store(key, false), val}
+		t.size++
+		t.gen++
+		return
+	}
+	// walk for best member remembering the path
+	var buf [32]*Ref[V]
+	path := buf[:0]
+	p := &t.root
+	for p.node != nil {
+		path = append(path, p)
+		// try next node
+		p = &p.node.child[p.node.dir(key)]
+	}
+	off, bit, pdir, differ := critbit(key, p.Key)
+
+	if ! differ {
+		// key exists - replace its value or delete it
+		if val, ok = update(p.Val, true); ok {
+			p.Val = val
+			return
+		}
+		t.size--
+		t.gen++
+		t.arena.Release(p.Key)
+		if len(path) == 0 {
+			t.root = Ref[V]{}
+			return zero, false
+		}
+		// the sibling of the leaf takes the place of its parent
+		wp := path[len(path)-1]
+		for _, r := range path[:len(path)-1] {
+			r.node.size--
+		}
+		*wp = wp.node.child[1-wp.node.dir(key)]
+		return zero, false
+	}
+	if val, ok = update(zero, false); ! ok {
+		return zero, false
+	}
+	// the insertion point is the first ref on the path below the crit bit
+	// (or the leaf)
+	wp := p
+	for _, r := range path {
+		if n := r.node; n.off > off || n.off == off && n.bit < bit {
+			wp = r
+			break
+		}
+		r.node.size++
+	}
+	// insert new node
+	nn := &Node[V]{off:off, bit:bit}
+	nn.child[1-pdir].Item = Item[V]{t.arena.Store(key, false), val}
+	nn.child[pdir] = *wp
+	nn.size = wp.count() + 1
+	wp.node = nn
+	wp.Item = Item[V]{}
+	t.size++
+	t.gen++
+
+	return
+}
+
+// GetOrSet returns the value of a key if it exists (loaded is true).
+// Otherwise it stores a given value and returns it.
+func (t *Dict[V]) GetOrSet(key []byte, val V) (actual V, loaded bool) {
+	actual, _ = t.Update(key, func(old V, exists bool) (V, bool) {
+		if exists {
+			loaded = true
+			return old, true
+		}
+		return val, true
+	})
+	return
+}
+
+// CompareAndSwap replaces the value of a key with new if the current value
+// equals old according to eq (reflect.DeepEqual if nil). A missing key is
+// never swapped. Returns whether the value was replaced.
+func (t *Dict[V]) CompareAndSwap(key []byte, old, new V, eq func(a, b V) bool) (swapped bool) {
+	if eq == nil {
+		eq = func(a, b V) bool { return reflect.DeepEqual(a, b) }
+	}
+	t.Update(key, func(cur V, exists bool) (V, bool) {
+		if exists && eq(cur, old) {
+			swapped = true
+			return new, true
+		}
+		return cur, exists
+	})
+	return
+}
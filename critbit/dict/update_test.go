@@ -0,0 +1,115 @@
+package dict
+
+import "testing"
+
+func Test_Update(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	gen := tr.gen
+
+	// modify an existing key (no structural change)
+	val, ok := tr.Update([]byte("bb"), func(old int, exists bool) (int, bool) {
+		if ! exists || old != 5 {
+			t.Errorf("wrong old value: %d, %v", old, exists)
+		}
+		return old * 10, true
+	})
+	if val != 50 || ! ok || tr.gen != gen {
+		t.Errorf("Update(bb) -> %d, %v (gen %d)", val, ok, tr.gen)
+	}
+
+	// delete an existing key
+	val, ok = tr.Update([]byte("aab"), func(old int, exists bool) (int, bool) {
+		return 7, false
+	})
+	if _, found := tr.Get([]byte("aab")); val != 0 || ok || found || tr.Len() != 7 || tr.gen == gen {
+		t.Errorf("Update(aab) -> %d, %v (len %d)", val, ok, tr.Len())
+	}
+
+	// a missing key is not inserted unless kept
+	val, ok = tr.Update([]byte("c"), func(old int, exists bool) (int, bool) {
+		if exists || old != 0 {
+			t.Errorf("wrong old value: %d, %v", old, exists)
+		}
+		return 1, false
+	})
+	if _, found := tr.Get([]byte("c")); val != 0 || ok || found || tr.Len() != 7 {
+		t.Errorf("Update(c) -> %d, %v (len %d)", val, ok, tr.Len())
+	}
+	for _, key := range []string{"c", "a", "bab", "aaaa"} {
+		if val, ok := tr.Update([]byte(key), func(int, bool) (int, bool) { return 42, true }); val != 42 || ! ok {
+			t.Errorf("Update(%s) -> %d, %v", key, val, ok)
+		}
+	}
+	if tr.Len() != 11 || checkSizes(t, &tr.root) != 11 {
+		t.Errorf("wrong length %d", tr.Len())
+	}
+	if err := tr.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// delete everything
+	for _, key := range tr.Keys() {
+		if val, ok := tr.Update(key, func(int, bool) (int, bool) { return 1, false }); val != 0 || ok {
+			t.Errorf("Update(%s) -> %d, %v", key, val, ok)
+		}
+		checkSizes(t, &tr.root)
+		if err := tr.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ! tr.Empty() || tr.Len() != 0 {
+		t.Errorf("dict is not empty: %q", tr.Keys())
+	}
+	if val, ok := tr.Update([]byte("x"), func(int, bool) (int, bool) { return 1, false }); val != 0 || ok || ! tr.Empty() {
+		t.Errorf("Update(x) on an empty dict -> %d, %v", val, ok)
+	}
+	if val, ok := tr.Update([]byte("x"), func(int, bool) (int, bool) { return 1, true }); val != 1 || ! ok || tr.Len() != 1 {
+		t.Errorf("Update(x) on an empty dict -> %d, %v", val, ok)
+	}
+}
+
+func Test_GetOrSet(t *testing.T) {
+	tr := testDict(testDictKeys...)
+	if val, loaded := tr.GetOrSet([]byte("aa"), 100); val != 0 || ! loaded {
+		t.Errorf("GetOrSet(aa) -> %d, %v", val, loaded)
+	}
+	if val, loaded := tr.GetOrSet([]byte("c"), 100); val != 100 || loaded {
+		t.Errorf("GetOrSet(c) -> %d, %v", val, loaded)
+	}
+	if val, ok := tr.Get([]byte("c")); val != 100 || ! ok || tr.Len() != 9 {
+		t.Errorf("Get(c) -> %d, %v", val, ok)
+	}
+	checkSizes(t, &tr.root)
+}
+
+func Test_CompareAndSwap(t *testing.T) {
+	tr := NewDict[*int]()
+	one := 1
+	tr.Set([]byte("a"), &one)
+	tr.Set([]byte("nil"), nil)
+
+	tests := []struct {
+		key     string
+		old     *int
+		eq      func(a, b *int) bool
+		swapped bool
+	}{
+		{"a", new(int), nil, false},
+		{"a", &one, nil, true},
+		{"nil", nil, nil, true},
+		{"missing", nil, nil, false},
+		{"a", new(int), func(a, b *int) bool { return a != nil && b != nil }, true},
+	}
+	for i, test := range tests {
+		two := 2
+		if swapped := tr.CompareAndSwap([]byte(test.key), test.old, &two, test.eq); swapped != test.swapped {
+			t.Errorf("test %d: CompareAndSwap(%s) -> %v", i, test.key, swapped)
+		}
+		if val, ok := tr.Get([]byte(test.key)); test.swapped && val != &two {
+			t.Errorf("test %d: the value was not swapped: %v, %v", i, val, ok)
+		}
+	}
+	if _, found := tr.Get([]byte("missing")); found || tr.Len() != 2 {
+		t.Errorf("wrong keys: %q", tr.Keys())
+	}
+}
@@ -0,0 +1,90 @@
+package dict
+
+import "fmt"
+import "bytes"
+
+
+// Validate checks the invariants of the tree (including the subtree sizes
+// of the nodes) and returns an error describing the first violation found.
+// It is meant for tracking down a corruption (e.g. a key buffer reused
+// after insertion) and takes O(n * depth) time.
+func (t *Dict[V]) Validate() error {
+	if t.Empty() {
+		if t.size != 0 {
+			return fmt.Errorf("dict: empty tree has size %d", t.size)
+		}
+		return nil
+	}
+	v := validator[V]{}
+	if err := v.walk(&t.root); err != nil {
+		return err
+	}
+	if v.leaves != t.size {
+		return fmt.Errorf("dict: size is %d but there are %d keys", t.size, v.leaves)
+	}
+	return nil
+}
+
+// validator walks a tree remembering the path to the current ref
+type validator[V any] struct {
+	path   []*Node[V]
+	// sides[i] is the direction taken at path[i]
+	sides  []byte
+	prev   []byte
+	leaves int
+}
+
+func (v *validator[V]) walk(p *Ref[V]) error {
+	n := p.node
+	if n == nil {
+		key := p.Key
+		if len(key) == 0 {
+			return fmt.Errorf("dict: empty key at position %d", v.leaves)
+		}
+		if v.leaves > 0 && bytes.Compare(v.prev, key) >= 0 {
+			return fmt.Errorf("dict: key %q at position %d is not greater than the previous key %q", key, v.leaves, v.prev)
+		}
+		for i, a := range v.path {
+			if a.dir(key) != v.sides[i] {
+				return fmt.Errorf("dict: key %q is on the wrong side of the node (off=%d, bit=%#02x) at depth %d", key, a.off, a.bit, i)
+			}
+		}
+		if v.leaves > 0 {
+			// the previous key is the rightmost one on the left of the deepest
+			// node the path turns right at, so they differ in its crit bit
+			i := bytes.LastIndexByte(v.sides, 1)
+			a := v.path[i]
+			if off, bit, _, _ := critbit(key, v.prev); off != a.off || bit != a.bit {
+				return fmt.Errorf("dict: key %q differs from the previous key %q in (off=%d, bit=%#02x) instead of the crit bit of the node (off=%d, bit=%#02x) at depth %d", key, v.prev, off, bit, a.off, a.bit, i)
+			}
+		}
+		v.prev = key
+		v.leaves++
+		return nil
+	}
+	depth := len(v.path)
+	if n.bit == 0 || n.bit & (n.bit-1) != 0 {
+		return fmt.Errorf("dict: node (off=%d, bit=%#02x) at depth %d has no single crit bit", n.off, n.bit, depth)
+	}
+	if depth > 0 {
+		// crit bits must strictly increase along the path
+		if a := v.path[depth-1]; n.off < a.off || n.off == a.off && n.bit >= a.bit {
+			return fmt.Errorf("dict: node (off=%d, bit=%#02x) at depth %d is not below its parent (off=%d, bit=%#02x)", n.off, n.bit, depth, a.off, a.bit)
+		}
+	}
+	leaves := v.leaves
+	v.path  = append(v.path, n)
+	v.sides = append(v.sides, 0)
+	for dir := byte(0); dir < 2; dir++ {
+		v.sides[depth] = dir
+		if err := v.walk(&n.child[dir]); err != nil {
+			return err
+		}
+	}
+	v.path, v.sides = v.path[:depth], v.sides[:depth]
+
+	if n.size != v.leaves - leaves {
+		return fmt.Errorf("dict: node (off=%d, bit=%#02x) at depth %d has size %d but there are %d keys below it", n.off, n.bit, depth, n.size, v.leaves - leaves)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package dict
+
+import "testing"
+import "strings"
+
+func Test_Validate(t *testing.T) {
+	newSet := func() *Dict[int] {
+		return NewDict(Item[int]{[]byte("abc"), 1}, Item[int]{[]byte("abd"), 2}, Item[int]{[]byte("b"), 3}, Item[int]{[]byte("ba"), 4})
+	}
+	for _, tr := range []*Dict[int]{NewDict[int](), NewDict(Item[int]{[]byte("a"), 1}), newSet()} {
+		if err := tr.Validate(); err != nil {
+			t.Errorf("%q: unexpected error %v", tr.Keys(), err)
+		}
+	}
+
+	tests := []struct {
+		corrupt func(*Dict[int])
+		err     string
+	}{
+		// a key buffer reused after insertion
+		{func(tr *Dict[int]) { tr.root.node.child[0].node.child[1].Key[2] = 'a' }, "not greater than the previous key"},
+		{func(tr *Dict[int]) { tr.root.node.child[0].node.child[1].Key[1] = 'c' }, "instead of the crit bit"},
+		{func(tr *Dict[int]) { c := &tr.root.node.child; c[0], c[1] = c[1], c[0] }, "wrong side"},
+		{func(tr *Dict[int]) { tr.size++ }, "size is 5 but there are 4 keys"},
+		{func(tr *Dict[int]) { tr.root.node.child[1].node.size++ }, "has size 3 but there are 2 keys below it"},
+		{func(tr *Dict[int]) { tr.root.node.bit = 0x03 }, "no single crit bit"},
+		{func(tr *Dict[int]) { tr.root.node.child[0].node.off = 0 }, "not below its parent"},
+		{func(tr *Dict[int]) { tr.root.node.child[1].node.child[1].Key = []byte("b") }, "not greater than the previous key"},
+		{func(tr *Dict[int]) { tr.root.node.child[0].node.child[0].Key = nil }, "empty key"},
+	}
+	for i, test := range tests {
+		tr := newSet()
+		test.corrupt(tr)
+		if err := tr.Validate(); err == nil || ! strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected %q error, got %v", i, test.err, err)
+		}
+	}
+}
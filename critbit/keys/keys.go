@@ -0,0 +1,296 @@
+// Package keys encodes typed values and tuples of them into byte keys which
+// sort (by bytes.Compare and so in critbit containers) in the order of the
+// values. Every element starts with a non-zero type tag, so a key is never
+// empty and never equals another key padded with zero bytes, which critbit
+// trees could not tell apart.
+//
+// Elements of different types sort by type: false < true < ints < uints <
+// floats < byte strings < strings. A tuple sorts before its extensions, so
+// encoded leading elements of tuples make a prefix for Iter and the like.
+package keys
+
+import "errors"
+import "fmt"
+import "math"
+import "encoding/binary"
+
+
+// Type tags of the elements
+const (
+	tagFalse  byte = 0x02
+	tagTrue   byte = 0x03
+	tagInt    byte = 0x10
+	tagUint   byte = 0x11
+	tagFloat  byte = 0x12
+	tagBytes  byte = 0x20
+	tagString byte = 0x21
+)
+
+// Byte strings are escaped: a zero byte is followed by escZero, and the
+// string is terminated with a zero byte followed by escEnd.
+const (
+	escEnd  byte = 0x01
+	escZero byte = 0xFF
+)
+
+var (
+	ErrBadKey      = errors.New("keys: malformed key")
+	ErrType        = errors.New("keys: element of another type")
+	ErrUnsupported = errors.New("keys: unsupported element type")
+)
+
+// AppendInt appends a signed integer (8 bytes with the sign bit flipped)
+func AppendInt(dst []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, tagInt), uint64(v) ^ 1<<63)
+}
+
+// AppendUint appends an unsigned integer (8 bytes big-endian)
+func AppendUint(dst []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, tagUint), v)
+}
+
+// AppendFloat appends a float. Negative numbers have all bits flipped and
+// positive ones the sign bit only, so -0 sorts before +0 and NaNs go to the
+// ends according to their sign.
+func AppendFloat(dst []byte, v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits & (1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits ^= 1<<63
+	}
+	return binary.BigEndian.AppendUint64(append(dst, tagFloat), bits)
+}
+
+// AppendBool appends a boolean (its tag alone)
+func AppendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, tagTrue)
+	}
+	return append(dst, tagFalse)
+}
+
+// AppendString appends an escaped and terminated string
+func AppendString(dst []byte, s string) []byte {
+	return appendEscaped(append(dst, tagString), s, true)
+}
+
+// AppendBytes appends an escaped and terminated byte string
+func AppendBytes(dst []byte, b []byte) []byte {
+	return appendEscaped(append(dst, tagBytes), string(b), true)
+}
+
+// AppendStringPrefix appends a string without the terminator, so the result
+// is a prefix of the keys whose string element (at that position) starts with s.
+func AppendStringPrefix(dst []byte, s string) []byte {
+	return appendEscaped(append(dst, tagString), s, false)
+}
+
+func appendEscaped(dst []byte, s string, term bool) []byte {
+	for i := 0; i < len(s); i++ {
+		if dst = append(dst, s[i]); s[i] == 0 {
+			dst = append(dst, escZero)
+		}
+	}
+	if term {
+		dst = append(dst, 0, escEnd)
+	}
+	return dst
+}
+
+// Append appends a tuple of elements. Supported types are bool, all ints
+// and uints, float32, float64, string and []byte.
+func Append(dst []byte, elems ...any) ([]byte, error) {
+	for _, e := range elems {
+		switch v := e.(type) {
+		case bool:
+			dst = AppendBool(dst, v)
+		case int:
+			dst = AppendInt(dst, int64(v))
+		case int8:
+			dst = AppendInt(dst, int64(v))
+		case int16:
+			dst = AppendInt(dst, int64(v))
+		case int32:
+			dst = AppendInt(dst, int64(v))
+		case int64:
+			dst = AppendInt(dst, v)
+		case uint:
+			dst = AppendUint(dst, uint64(v))
+		case uint8:
+			dst = AppendUint(dst, uint64(v))
+		case uint16:
+			dst = AppendUint(dst, uint64(v))
+		case uint32:
+			dst = AppendUint(dst, uint64(v))
+		case uint64:
+			dst = AppendUint(dst, v)
+		case float32:
+			dst = AppendFloat(dst, float64(v))
+		case float64:
+			dst = AppendFloat(dst, v)
+		case string:
+			dst = AppendString(dst, v)
+		case []byte:
+			dst = AppendBytes(dst, v)
+		default:
+			return dst, fmt.Errorf("%w: %T", ErrUnsupported, e)
+		}
+	}
+	return dst, nil
+}
+
+// Encode returns a key of a tuple (see Append)
+func Encode(elems ...any) ([]byte, error) {
+	return Append(nil, elems...)
+}
+
+// Prefix encodes the leading elements of tuples. All keys of tuples starting
+// with them begin with the result, so it can be passed to Iter, CountPrefix
+// or DeletePrefix. As a key, it is the least one of such tuples (FindPathGE).
+func Prefix(elems ...any) ([]byte, error) {
+	return Append(nil, elems...)
+}
+
+// PrefixEnd returns the least key greater than all keys with a given prefix
+// (an exclusive upper bound for IterRange) or nil if there is no such key.
+func PrefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] != 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// -- decoding: every func returns the rest of the key after the element --
+
+// DecodeInt decodes a signed integer
+func DecodeInt(key []byte) (int64, []byte, error) {
+	v, rest, err := decodeFixed(key, tagInt)
+	if err != nil {
+		return 0, key, err
+	}
+	return int64(v ^ 1<<63), rest, nil
+}
+
+// DecodeUint decodes an unsigned integer
+func DecodeUint(key []byte) (uint64, []byte, error) {
+	return decodeFixed(key, tagUint)
+}
+
+// DecodeFloat decodes a float
+func DecodeFloat(key []byte) (float64, []byte, error) {
+	bits, rest, err := decodeFixed(key, tagFloat)
+	if err != nil {
+		return 0, key, err
+	}
+	if bits & (1<<63) != 0 {
+		bits ^= 1<<63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits), rest, nil
+}
+
+// DecodeBool decodes a boolean
+func DecodeBool(key []byte) (bool, []byte, error) {
+	if err := checkTag(key, tagFalse, tagTrue); err != nil {
+		return false, key, err
+	}
+	return key[0] == tagTrue, key[1:], nil
+}
+
+// DecodeString decodes a string
+func DecodeString(key []byte) (string, []byte, error) {
+	b, rest, err := decodeEscaped(key, tagString)
+	return string(b), rest, err
+}
+
+// DecodeBytes decodes a byte string (a new slice)
+func DecodeBytes(key []byte) ([]byte, []byte, error) {
+	return decodeEscaped(key, tagBytes)
+}
+
+// Decode decodes a tuple. Elements are returned as bool, int64, uint64,
+// float64, string or []byte.
+func Decode(key []byte) (elems []any, err error) {
+	for len(key) > 0 {
+		var e any
+		switch key[0] {
+		case tagFalse, tagTrue:
+			e, key, err = DecodeBool(key)
+		case tagInt:
+			e, key, err = DecodeInt(key)
+		case tagUint:
+			e, key, err = DecodeUint(key)
+		case tagFloat:
+			e, key, err = DecodeFloat(key)
+		case tagString:
+			e, key, err = DecodeString(key)
+		case tagBytes:
+			e, key, err = DecodeBytes(key)
+		default:
+			err = fmt.Errorf("%w: unknown tag 0x%02x", ErrBadKey, key[0])
+		}
+		if err != nil {
+			return elems, err
+		}
+		elems = append(elems, e)
+	}
+	return elems, nil
+}
+
+// checkTag returns an error unless the key starts with one of the tags
+func checkTag(key []byte, tags ...byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("%w: no more elements", ErrBadKey)
+	}
+	for _, tag := range tags {
+		if key[0] == tag {
+			return nil
+		}
+	}
+	switch key[0] {
+	case tagFalse, tagTrue, tagInt, tagUint, tagFloat, tagString, tagBytes:
+		return ErrType
+	}
+	return fmt.Errorf("%w: unknown tag 0x%02x", ErrBadKey, key[0])
+}
+
+func decodeFixed(key []byte, tag byte) (uint64, []byte, error) {
+	if err := checkTag(key, tag); err != nil {
+		return 0, key, err
+	}
+	if len(key) < 9 {
+		return 0, key, fmt.Errorf("%w: truncated number", ErrBadKey)
+	}
+	return binary.BigEndian.Uint64(key[1:9]), key[9:], nil
+}
+
+func decodeEscaped(key []byte, tag byte) ([]byte, []byte, error) {
+	if err := checkTag(key, tag); err != nil {
+		return nil, key, err
+	}
+	res := []byte{}
+	for i := 1; i < len(key); i++ {
+		if key[i] != 0 {
+			res = append(res, key[i])
+			continue
+		}
+		if i++; i == len(key) {
+			break
+		}
+		switch key[i] {
+		case escZero:
+			res = append(res, 0)
+		case escEnd:
+			return res, key[i+1:], nil
+		default:
+			return nil, key, fmt.Errorf("%w: bad escape 0x%02x", ErrBadKey, key[i])
+		}
+	}
+	return nil, key, fmt.Errorf("%w: unterminated string", ErrBadKey)
+}
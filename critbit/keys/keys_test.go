@@ -0,0 +1,181 @@
+package keys
+
+import "testing"
+import "bytes"
+import "errors"
+import "math"
+import "reflect"
+import "analyzers/lib/critbit/dict"
+
+func Test_Order(t *testing.T) {
+	// every tuple must encode to a key greater than the previous one
+	tuples := [][]any{
+		{false},
+		{true},
+		{math.MinInt64},
+		{-1000},
+		{-1},
+		{0},
+		{0, "a"},
+		{1},
+		{math.MaxInt64},
+		{uint64(0)},
+		{uint64(1)},
+		{uint64(math.MaxUint64)},
+		{math.Inf(-1)},
+		{-1.5},
+		{-1e-300},
+		{math.Copysign(0, -1)},
+		{0.0},
+		{1e-300},
+		{1.5},
+		{math.Inf(1)},
+		{[]byte{}},
+		{[]byte{0}},
+		{""},
+		{"", -1},
+		{"", 1},
+		{"\x00"},
+		{"\x00\x00"},
+		{"\x00\x01"},
+		{"a"},
+		{"a", false},
+		{"a", "b"},
+		{"a\x00"},
+		{"a\x00b"},
+		{"a\x01"},
+		{"ab"},
+		{"b\xff"},
+	}
+	var prev []byte
+	seen := map[string]bool{}
+	for i, tuple := range tuples {
+		key, err := Encode(tuple...)
+		if err != nil {
+			t.Fatalf("%v: %v", tuple, err)
+		}
+		if i > 0 && bytes.Compare(prev, key) >= 0 {
+			t.Errorf("%v (%x) does not sort after %v (%x)", tuple, key, tuples[i-1], prev)
+		}
+		// critbit trees cannot tell keys differing in trailing zeros apart
+		if trimmed := string(bytes.TrimRight(key, "\x00")); seen[trimmed] {
+			t.Errorf("%v (%x) equals another key padded with zeros", tuple, key)
+		} else {
+			seen[trimmed] = true
+		}
+		prev = key
+	}
+}
+
+func Test_RoundTrip(t *testing.T) {
+	tuples := [][]any{
+		{true, int64(-5), uint64(7), -2.5, "x\x00y", []byte{0, 0xFF}},
+		{math.Inf(-1), math.MinInt64, ""},
+		{int8(-3), uint16(3), float32(0.5), []byte{}},
+	}
+	expected := [][]any{
+		{true, int64(-5), uint64(7), -2.5, "x\x00y", []byte{0, 0xFF}},
+		{math.Inf(-1), int64(math.MinInt64), ""},
+		{int64(-3), uint64(3), 0.5, []byte{}},
+	}
+	for i, tuple := range tuples {
+		key, _ := Encode(tuple...)
+		if elems, err := Decode(key); err != nil || ! reflect.DeepEqual(elems, expected[i]) {
+			t.Errorf("Decode(Encode(%v)) -> %v, %v", tuple, elems, err)
+		}
+	}
+
+	key := AppendUint(AppendString(nil, "tenant"), 42)
+	s, rest, err := DecodeString(key)
+	if s != "tenant" || err != nil {
+		t.Errorf("DecodeString -> %q, %v", s, err)
+	}
+	if _, _, err := DecodeInt(rest); err != ErrType {
+		t.Errorf("DecodeInt of a uint -> %v", err)
+	}
+	if v, rest, err := DecodeUint(rest); v != 42 || len(rest) != 0 || err != nil {
+		t.Errorf("DecodeUint -> %v, %q, %v", v, rest, err)
+	}
+}
+
+func Test_Errors(t *testing.T) {
+	if _, err := Encode("a", struct{}{}); ! errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	for _, key := range [][]byte{
+		{0x7F},
+		{tagInt, 1, 2},
+		{tagString, 'a'},
+		{tagString, 'a', 0},
+		{tagString, 'a', 0, 2},
+	} {
+		if _, err := Decode(key); ! errors.Is(err, ErrBadKey) {
+			t.Errorf("Decode(%x): expected ErrBadKey, got %v", key, err)
+		}
+	}
+}
+
+func Test_DictPrefix(t *testing.T) {
+	type event struct {
+		tenant string
+		ts     int64
+		id     uint64
+	}
+	events := []event{
+		{"acme", 5, 1}, {"acme", -10, 2}, {"acme", 0, 3}, {"acme", -10, 1},
+		{"ac", 1, 1}, {"acme2", -1, 1}, {"acme\x00", 3, 1},
+	}
+	d := dict.NewDict[event]()
+	for _, e := range events {
+		key, _ := Encode(e.tenant, e.ts, e.id)
+		d.Set(key, e)
+	}
+
+	// all events of the tenant in the timestamp order
+	prefix, _ := Prefix("acme")
+	var got []event
+	d.Iter(prefix, func(item dict.Item[event]) bool {
+		got = append(got, item.Val)
+		return true
+	})
+	exp := []event{{"acme", -10, 1}, {"acme", -10, 2}, {"acme", 0, 3}, {"acme", 5, 1}}
+	if ! reflect.DeepEqual(got, exp) {
+		t.Errorf("Iter(%q) -> %v", prefix, got)
+	}
+
+	// the first event of the tenant at or after a timestamp
+	start, _ := Prefix("acme", 0)
+	if leaf := d.FindPathGE(start).GetLeaf(); leaf == nil || leaf.Val != (event{"acme", 0, 3}) {
+		t.Errorf("FindPathGE(%q) -> %v", start, leaf)
+	}
+
+	// tenants starting with "acme"
+	got = got[:0]
+	prefix = AppendStringPrefix(nil, "acme")
+	d.IterRange(prefix, PrefixEnd(prefix), dict.RangeOpts{HiExclusive: true}, func(item dict.Item[event]) bool {
+		got = append(got, item.Val)
+		return true
+	})
+	if len(got) != 6 || got[0] != (event{"acme", -10, 1}) || got[5] != (event{"acme2", -1, 1}) {
+		t.Errorf("IterRange(%q) -> %v", prefix, got)
+	}
+	if n := d.CountPrefix(prefix); n != 6 {
+		t.Errorf("CountPrefix(%q) -> %d", prefix, n)
+	}
+}
+
+func Test_PrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix, end []byte
+	}{
+		{[]byte{1, 2}, []byte{1, 3}},
+		{[]byte{1, 0xFF}, []byte{2}},
+		{[]byte{0xFF, 0xFF}, nil},
+		{nil, nil},
+	}
+	for _, test := range tests {
+		if end := PrefixEnd(test.prefix); ! bytes.Equal(end, test.end) {
+			t.Errorf("PrefixEnd(%x) -> %x, expected %x", test.prefix, end, test.end)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package pattern compiles globs and regular expressions into automata which
+// consume keys byte by byte. Tree containers feed them the prefixes shared by
+// their subtrees and skip the subtrees once the automaton is dead.
+package pattern
+
+import "errors"
+import "strings"
+import "strconv"
+import "sync"
+import "unicode/utf8"
+import "regexp/syntax"
+
+
+var ErrBadPattern = errors.New("pattern: syntax error in pattern")
+
+// Matcher is a compiled pattern. A key matches only as a whole, as if the
+// pattern were anchored at both ends. It is safe for concurrent use.
+type Matcher struct {
+	prog    *syntax.Prog
+	// walkers are reused between calls, so the marks are not allocated anew
+	walkers sync.Pool
+}
+
+// State is a matcher state after a key prefix. States are immutable values.
+type State struct {
+	// pcs are the threads waiting for the next rune (not closed yet)
+	pcs     []uint32
+	// prev is the last rune (-1 at the start)
+	prev    rune
+	// pending is an incomplete UTF-8 sequence at the end of the prefix
+	pending []byte
+}
+
+// Glob compiles a pattern with the syntax of path.Match:
+//
+//	'*'         matches any sequence of non-/ characters
+//	'?'         matches any single non-/ character
+//	'[' [ '^' ] { character-range } ']'
+//	            character class (must be non-empty)
+//	c           matches character c (c != '*', '?', '\\', '[')
+//	'\\' c      matches character c
+//
+// where character-range is c or lo '-' hi, and c inside a class may be
+// escaped with '\\' as well.
+func Glob(glob string) (*Matcher, error) {
+	var expr strings.Builder
+	for i := 0; i < len(glob); {
+		switch c := glob[i]; c {
+		case '*':
+			expr.WriteString(`[^/]*`)
+			i++
+		case '?':
+			expr.WriteString(`[^/]`)
+			i++
+		case '[':
+			n, err := globClass(&expr, glob[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+		case '\\':
+			if i++; i == len(glob) {
+				return nil, ErrBadPattern
+			}
+			fallthrough
+		default:
+			_, n := utf8.DecodeRuneInString(glob[i:])
+			expr.WriteString(quoteRune(glob[i:i+n]))
+			i += n
+		}
+	}
+	return Regexp(expr.String())
+}
+
+// globClass translates a character class following '[' and returns the
+// number of bytes consumed including the closing ']'.
+func globClass(expr *strings.Builder, glob string) (int, error) {
+	i := 0
+	expr.WriteByte('[')
+	if i < len(glob) && glob[i] == '^' {
+		expr.WriteByte('^')
+		i++
+	}
+	// next reads a possibly escaped character of the class, only an
+	// unescaped '-' or ']' is not a character
+	next := func() (string, error) {
+		escaped := i < len(glob) && glob[i] == '\\'
+		if escaped {
+			i++
+		}
+		if i == len(glob) || ! escaped && (glob[i] == '-' || glob[i] == ']') {
+			return "", ErrBadPattern
+		}
+		_, n := utf8.DecodeRuneInString(glob[i:])
+		i += n
+		return quoteRune(glob[i-n:i]), nil
+	}
+	for ranges := 0; ; ranges++ {
+		if i < len(glob) && glob[i] == ']' && ranges > 0 {
+			expr.WriteByte(']')
+			return i + 1, nil
+		}
+		lo, err := next()
+		if err != nil {
+			return 0, err
+		}
+		expr.WriteString(lo)
+		if i < len(glob) && glob[i] == '-' {
+			i++
+			hi, err := next()
+			if err != nil {
+				return 0, err
+			}
+			expr.WriteString("-" + hi)
+		}
+	}
+}
+
+// quoteRune returns the first character of s escaped for regexp syntax
+func quoteRune(s string) string {
+	r, _ := utf8.DecodeRuneInString(s)
+	return `\x{` + strconv.FormatInt(int64(r), 16) + `}`
+}
+
+// Regexp compiles a regular expression with the Perl syntax (see regexp/syntax).
+func Regexp(expr string) (*Matcher, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(re)
+}
+
+// Compile makes a Matcher of a parsed regular expression.
+func Compile(re *syntax.Regexp) (*Matcher, error) {
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	m := &Matcher{prog: prog}
+	m.walkers.New = func() any {
+		return &walker{prog: prog, mark: make([]uint32, len(prog.Inst))}
+	}
+	return m, nil
+}
+
+// Start returns the state before any key byte
+func (m *Matcher) Start() State {
+	return State{pcs: []uint32{uint32(m.prog.Start)}, prev: -1}
+}
+
+// Dead reports whether no key with the prefix of the state can match
+func (s State) Dead() bool {
+	return len(s.pcs) == 0
+}
+
+// Feed returns the state after feeding more key bytes to a given state
+func (m *Matcher) Feed(s State, key []byte) State {
+	if len(key) == 0 || s.Dead() {
+		return s
+	}
+	w := m.walkers.Get().(*walker)
+	defer m.walkers.Put(w)
+	pcs := append([]uint32(nil), s.pcs...)
+	prev := s.prev
+	buf := append(append([]byte(nil), s.pending...), key...)
+	for utf8.FullRune(buf) && len(pcs) > 0 {
+		r, n := utf8.DecodeRune(buf)
+		pcs = w.step(pcs, syntax.EmptyOpContext(prev, r), r)
+		prev, buf = r, buf[n:]
+	}
+	if len(pcs) == 0 {
+		return State{}
+	}
+	return State{pcs: pcs, prev: prev, pending: buf}
+}
+
+// Match reports whether a key ending at the state matches
+func (m *Matcher) Match(s State) bool {
+	if s.Dead() {
+		return false
+	}
+	w := m.walkers.Get().(*walker)
+	defer m.walkers.Put(w)
+	pcs, prev := s.pcs, s.prev
+	// the rest of an incomplete sequence is made of invalid runes
+	for _, b := range s.pending {
+		r, _ := utf8.DecodeRune([]byte{b})
+		if pcs = w.step(pcs, syntax.EmptyOpContext(prev, r), r); len(pcs) == 0 {
+			return false
+		}
+		prev = r
+	}
+	for _, pc := range w.closure(pcs, syntax.EmptyOpContext(prev, -1)) {
+		if m.prog.Inst[pc].Op == syntax.InstMatch {
+			return true
+		}
+	}
+	return false
+}
+
+
+// walker runs the threads of a program (a Pike VM without captures)
+type walker struct {
+	prog *syntax.Prog
+	// mark[pc] == gen if the pc was visited by the current closure
+	mark []uint32
+	gen  uint32
+}
+
+// closure follows the empty transitions of the threads satisfying flag
+// and returns the threads waiting for a rune or matching.
+func (w *walker) closure(pcs []uint32, flag syntax.EmptyOp) (res []uint32) {
+	if w.gen++; w.gen == 0 {
+		// the counter wrapped around, forget the old marks
+		clear(w.mark)
+		w.gen = 1
+	}
+	stack := append([]uint32(nil), pcs...)
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if w.mark[pc] == w.gen {
+			continue
+		}
+		w.mark[pc] = w.gen
+		inst := &w.prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Arg, inst.Out)
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg) &^ flag == 0 {
+				stack = append(stack, inst.Out)
+			}
+		case syntax.InstMatch, syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			res = append(res, pc)
+		}
+	}
+	return
+}
+
+// step advances the threads over a rune
+func (w *walker) step(pcs []uint32, flag syntax.EmptyOp, r rune) (next []uint32) {
+	for _, pc := range w.closure(pcs, flag) {
+		inst := &w.prog.Inst[pc]
+		ok := false
+		switch inst.Op {
+		case syntax.InstRune, syntax.InstRune1:
+			ok = inst.MatchRune(r)
+		case syntax.InstRuneAny:
+			ok = true
+		case syntax.InstRuneAnyNotNL:
+			ok = r != '\n'
+		}
+		if ok {
+			next = append(next, inst.Out)
+		}
+	}
+	return
+}
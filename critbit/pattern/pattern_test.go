@@ -0,0 +1,125 @@
+package pattern
+
+import "testing"
+import "path"
+import "regexp"
+
+var testKeys = []string{
+	"", "a", "ab", "abc", "a/b", "a/b/c", "user/1/settings", "user/12/settings",
+	"user/1/2/settings", "user//settings", "ключ", "к", "x\xffy", "\xd0", "a\nb", "word up",
+	"]", "-", "\\", "a-z", "z",
+}
+
+// feedBytes feeds a key one byte at a time
+func feedBytes(m *Matcher, key string) bool {
+	s := m.Start()
+	for i := 0; i < len(key); i++ {
+		s = m.Feed(s, []byte{key[i]})
+	}
+	return m.Match(s)
+}
+
+func Test_Glob(t *testing.T) {
+	globs := []string{
+		"*", "?", "a*", "a?", "*/*", "a/*/c", "user/*/settings", "[a-c]*", "[^a]*",
+		"a[/]b", "[^b]/*", "\\a*", "к*", "?", "x?y", "[к]", "*b*", "a\nb", "[a-z]*[!-~]?",
+		"[\\]]", "[\\-]", "[a\\-z]", "[\\\\]", "[^\\]]*", "a[\\-]z", "[\\]-a]",
+	}
+	for _, glob := range globs {
+		m, err := Glob(glob)
+		_, perr := path.Match(glob, "")
+		if (err != nil) != (perr != nil) {
+			t.Errorf("Glob(%q) -> %v, path.Match -> %v", glob, err, perr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		for _, key := range testKeys {
+			expected, _ := path.Match(glob, key)
+			if ok := m.Match(m.Feed(m.Start(), []byte(key))); ok != expected {
+				t.Errorf("Glob(%q) on %q -> %v, expected %v", glob, key, ok, expected)
+			}
+			if ok := feedBytes(m, key); ok != expected {
+				t.Errorf("Glob(%q) on %q byte by byte -> %v, expected %v", glob, key, ok, expected)
+			}
+		}
+	}
+	for _, glob := range []string{"[", "[]", "[a-]", "[-a]", "a\\", "[a"} {
+		if _, err := Glob(glob); err != ErrBadPattern {
+			t.Errorf("Glob(%q) must fail, got %v", glob, err)
+		}
+	}
+}
+
+func Test_Regexp(t *testing.T) {
+	exprs := []string{
+		`a.*`, `a|ab|abc`, `(?i)A.C`, `user/\d+/settings`, `[^/]+/[^/]+`, `\w+ \w+`,
+		`.*\bup`, `a$`, `^a`, `(?m)a$\n^b`, `(?s)a.b`, `\p{Cyrillic}+`, `x.y`, `\xd0`, `.`, ``,
+	}
+	for _, expr := range exprs {
+		m, err := Regexp(expr)
+		if err != nil {
+			t.Errorf("Regexp(%q) failed: %v", expr, err)
+			continue
+		}
+		re := regexp.MustCompile(`^(?:` + expr + `)$`)
+		for _, key := range testKeys {
+			expected := re.MatchString(key)
+			if ok := m.Match(m.Feed(m.Start(), []byte(key))); ok != expected {
+				t.Errorf("Regexp(%q) on %q -> %v, expected %v", expr, key, ok, expected)
+			}
+			if ok := feedBytes(m, key); ok != expected {
+				t.Errorf("Regexp(%q) on %q byte by byte -> %v, expected %v", expr, key, ok, expected)
+			}
+		}
+	}
+	if _, err := Regexp(`a(`); err == nil {
+		t.Error("Regexp(`a(`) must fail")
+	}
+}
+
+func Test_Dead(t *testing.T) {
+	m, _ := Glob("user/*/settings")
+	tests := []struct {
+		prefix string
+		dead   bool
+	}{
+		{"", false}, {"us", false}, {"user/123", false}, {"user/123/se", false},
+		{"admin", true}, {"user/1/settingsX", true}, {"usex", true},
+	}
+	for _, test := range tests {
+		if s := m.Feed(m.Start(), []byte(test.prefix)); s.Dead() != test.dead {
+			t.Errorf("prefix %q: Dead() -> %v, expected %v", test.prefix, s.Dead(), test.dead)
+		}
+	}
+}
+
+func Test_WalkerReuse(t *testing.T) {
+	m, _ := Glob("a*b")
+	// a wrapped mark counter must not leave stale marks behind
+	w := m.walkers.Get().(*walker)
+	w.gen = ^uint32(0) - 1
+	m.walkers.Put(w)
+	for i := 0; i < 4; i++ {
+		if ! m.Match(m.Feed(m.Start(), []byte("axxb"))) || m.Match(m.Feed(m.Start(), []byte("axx"))) {
+			t.Fatalf("wrong match in round %d", i)
+		}
+	}
+	// concurrent calls get walkers of their own
+	done := make(chan bool)
+	for i := 0; i < 8; i++ {
+		go func() {
+			ok := true
+			for j := 0; j < 100; j++ {
+				ok = ok && m.Match(m.Feed(m.Start(), []byte("ab"))) && ! m.Match(m.Feed(m.Start(), []byte("ba")))
+			}
+			done <- ok
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if ! <-done {
+			t.Error("wrong match in a concurrent call")
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package set
+
+import "testing"
+import "fmt"
+
+func Test_FuzzySearch(t *testing.T) {
+	tr := NewSet()
+	for _, s := range []string{"apple", "apply", "ample", "maple", "app", "applesauce", "banana", "a"} {
+		tr.Add([]byte(s))
+	}
+	tests := []struct {
+		query string
+		max   int
+		res   []string
+	}{
+		{"apple", 0, []string{"apple:0"}},
+		{"apple", 1, []string{"ample:1", "apple:0", "apply:1"}},
+		{"apple", 2, []string{"ample:1", "app:2", "apple:0", "apply:1", "maple:2"}},
+		{"aple",  1, []string{"ample:1", "apple:1", "maple:1"}},
+		{"",      1, []string{"a:1"}},
+		{"bananas", 1, []string{"banana:1"}},
+		{"xyz",   2, nil},
+		{"apple", -1, nil},
+	}
+	for i, test := range tests {
+		var res []string
+		tr.FuzzySearch([]byte(test.query), test.max, func(key []byte, dist int) bool {
+			res = append(res, fmt.Sprintf("%s:%d", key, dist))
+			return true
+		})
+		if fmt.Sprint(res) != fmt.Sprint(test.res) {
+			t.Errorf("test %d: FuzzySearch(%q, %d) -> %q, expected %q", i, test.query, test.max, res, test.res)
+		}
+	}
+
+	// abort after two keys
+	n := 0
+	if tr.FuzzySearch([]byte("apple"), 2, func([]byte, int) bool { n++; return n < 2 }) || n != 2 {
+		t.Errorf("FuzzySearch must stop when the handler returns false (%d calls)", n)
+	}
+}
@@ -0,0 +1,97 @@
+package set
+
+import "bytes"
+import "errors"
+
+
+var ErrBadToken = errors.New("set: bad continuation token")
+
+// List tokens are a kind byte followed by the last reported key or prefix
+const (
+	// tokenKey resumes after the key
+	tokenKey    = 'k'
+	// tokenPrefix resumes after all keys with the common prefix
+	tokenPrefix = 'p'
+)
+
+// List calls a handler for the keys with a given prefix like a listing of
+// a directory: keys containing the delimiter after the prefix are grouped by
+// their common prefix up to the delimiter, which is reported once (isPrefix
+// is set), and the rest of its subtree is skipped. An empty delimiter lists
+// all the keys.
+//
+// If the handler aborts by returning false, List returns a token to pass to
+// a later call (with the same prefix and delimiter) to resume the listing
+// after the last entry. A nil token starts from the beginning and a nil next
+// token means the listing is complete.
+func (t *Set) List(prefix, delim, token []byte, handler func(key []byte, isPrefix bool) bool) (next []byte, err error) {
+	start, after := prefix, false
+	if token != nil {
+		if len(token) < 2 || ! bytes.HasPrefix(token[1:], prefix) {
+			return nil, ErrBadToken
+		}
+		switch start = token[1:]; token[0] {
+		case tokenKey:
+			after = true
+		case tokenPrefix:
+			if start = prefixEnd(start); start == nil {
+				return nil, nil
+			}
+		default:
+			return nil, ErrBadToken
+		}
+	}
+	for {
+		// iterate the keys until the next common prefix
+		var common []byte
+		t.iterateFrom(start, func(key []byte) bool {
+			switch {
+			case after && bytes.Equal(key, start):
+				return true
+			case ! bytes.HasPrefix(key, prefix):
+				return false
+			}
+			i := -1
+			if len(delim) > 0 {
+				i = bytes.Index(key[len(prefix):], delim)
+			}
+			if i >= 0 {
+				common = key[:len(prefix)+i+len(delim)]
+				return false
+			}
+			if ! handler(key, false) {
+				next = listToken(tokenKey, key)
+				return false
+			}
+			return true
+		})
+		if common == nil {
+			return
+		}
+		if ! handler(common, true) {
+			return listToken(tokenPrefix, common), nil
+		}
+		// skip the rest of the subtree: start from the next sibling of the prefix
+		if start, after = prefixEnd(common), false; start == nil {
+			return
+		}
+	}
+}
+
+// listToken makes a continuation token of a kind and a key
+func listToken(kind byte, key []byte) []byte {
+	return append([]byte{kind}, key...)
+}
+
+// prefixEnd returns the least key greater than all keys with a given prefix
+// (nil if there is no such key)
+func prefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			end := append([]byte(nil), prefix[:i+1]...)
+			end[i]++
+			return end
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package set
+
+import "testing"
+import "errors"
+import "slices"
+
+// listKeys are the keys of the listing tests, like paths of a directory tree
+var listKeys = []string{"a", "a/", "a/b/c.txt", "a/b/d.txt", "a/b/e/f", "a/x.txt", "a/y/z", "a\xff/1", "b/1", "b/2/3"}
+
+// listAll lists a set in pages of a given size and returns the entries
+func listAll(tr *Set, prefix, delim string, page int) (res []string, err error) {
+	var token []byte
+	for {
+		n := 0
+		token, err = tr.List([]byte(prefix), []byte(delim), token, func(key []byte, isPrefix bool) bool {
+			if isPrefix {
+				res = append(res, string(key) + "*")
+			} else {
+				res = append(res, string(key))
+			}
+			n++
+			return n < page
+		})
+		if err != nil || token == nil {
+			return
+		}
+	}
+}
+
+func Test_List(t *testing.T) {
+	tr := NewSet()
+	for _, s := range listKeys {
+		tr.Add([]byte(s))
+	}
+	tests := []struct {
+		prefix, delim string
+		exp           []string
+	}{
+		{"a/", "/", []string{"a/", "a/b/*", "a/x.txt", "a/y/*"}},
+		{"a/b/", "/", []string{"a/b/c.txt", "a/b/d.txt", "a/b/e/*"}},
+		{"", "/", []string{"a", "a/*", "a\xff/*", "b/*"}},
+		{"b", "/", []string{"b/*"}},
+		{"a/b", "", []string{"a/b/c.txt", "a/b/d.txt", "a/b/e/f"}},
+		{"a/", ".", []string{"a/", "a/b/c.*", "a/b/d.*", "a/b/e/f", "a/x.*", "a/y/z"}},
+		{"c", "/", nil},
+	}
+	for _, test := range tests {
+		for _, page := range []int{1, 2, 100} {
+			got, err := listAll(tr, test.prefix, test.delim, page)
+			if err != nil || ! slices.Equal(got, test.exp) {
+				t.Errorf("List(%q, %q) by %d -> %q, %v", test.prefix, test.delim, page, got, err)
+			}
+		}
+	}
+}
+
+func Test_ListToken(t *testing.T) {
+	tr := NewSet()
+	for _, s := range listKeys {
+		tr.Add([]byte(s))
+	}
+	var token []byte
+	token, _ = tr.List([]byte("a/"), []byte("/"), nil, func(key []byte, isPrefix bool) bool {
+		return ! isPrefix
+	})
+	if string(token) != "pa/b/" {
+		t.Errorf("wrong token %q", token)
+	}
+	// the token holds a position, so it survives modifications
+	tr.Add([]byte("a/c"))
+	tr.Del([]byte("a/x.txt"))
+	var got []string
+	token, err := tr.List([]byte("a/"), []byte("/"), token, func(key []byte, isPrefix bool) bool {
+		got = append(got, string(key))
+		return true
+	})
+	if token != nil || err != nil || ! slices.Equal(got, []string{"a/c", "a/y/"}) {
+		t.Errorf("resumed List -> %q, %q, %v", got, token, err)
+	}
+
+	for _, token := range []string{"", "k", "xa/b", "pb/1"} {
+		if _, err := tr.List([]byte("a/"), []byte("/"), []byte(token), func([]byte, bool) bool { return true }); ! errors.Is(err, ErrBadToken) {
+			t.Errorf("expected ErrBadToken for %q, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package set
+
+import "analyzers/lib/critbit/pattern"
+
+
+// Match calls a handler for every key which matches a pattern as a whole
+// (see pattern.Glob and pattern.Regexp) in key order. The pattern is fed
+// the prefixes shared by subtrees, so subtrees which cannot match are
+// skipped. It returns whether all matching keys were reported.
+func (t *Set) Match(m *pattern.Matcher, handler func([]byte) bool) bool {
+	if t.Empty() {
+		return true
+	}
+	return t.match(t.root, t.root.first(), 0, m, m.Start(), handler)
+}
+
+// match visits the subtree at p whose leftmost key is first; s is the
+// pattern state after depth key bytes.
+func (t *Set) match(p Ref, first []byte, depth int, m *pattern.Matcher, s pattern.State, h func([]byte) bool) bool {
+	if p.node == nil {
+		if m.Match(m.Feed(s, p.Key[depth:])) {
+			return h(p.Key)
+		}
+		return true
+	}
+	// all keys of the subtree share the bytes of the leftmost one before the
+	// crit byte (passed down to the left child, found anew for the right one)
+	off := min(p.node.off, len(first))
+	if s = m.Feed(s, first[depth:off]); s.Dead() {
+		return true
+	}
+	right := &p.node.child[1]
+	return t.match(p.node.child[0], first, off, m, s, h) && t.match(*right, right.first(), off, m, s, h)
+}
@@ -0,0 +1,57 @@
+package set
+
+import "testing"
+import "fmt"
+import "analyzers/lib/critbit/pattern"
+
+func Test_Match(t *testing.T) {
+	tr := NewSet()
+	for _, s := range []string{
+		"user/1/settings", "user/1/profile", "user/22/settings", "user/3/x/settings",
+		"users", "admin/settings", "user/4/settings/old",
+	} {
+		tr.Add([]byte(s))
+	}
+	glob := func(s string) *pattern.Matcher {
+		m, err := pattern.Glob(s)
+		if err != nil {
+			t.Fatalf("Glob(%q) failed: %v", s, err)
+		}
+		return m
+	}
+	regexp := func(s string) *pattern.Matcher {
+		m, err := pattern.Regexp(s)
+		if err != nil {
+			t.Fatalf("Regexp(%q) failed: %v", s, err)
+		}
+		return m
+	}
+	tests := []struct {
+		m   *pattern.Matcher
+		res []string
+	}{
+		{glob("user/*/settings"), []string{"user/1/settings", "user/22/settings"}},
+		{glob("user/?/*"), []string{"user/1/profile", "user/1/settings"}},
+		{glob("[a-u]*"), []string{"users"}},
+		{glob("*"), []string{"users"}},
+		{regexp(`user/\d+/.*settings.*`), []string{"user/1/settings", "user/22/settings", "user/3/x/settings", "user/4/settings/old"}},
+		{regexp(`(admin|user/3)/.*`), []string{"admin/settings", "user/3/x/settings"}},
+		{regexp(`nope.*`), nil},
+	}
+	for i, test := range tests {
+		var res []string
+		tr.Match(test.m, func(key []byte) bool {
+			res = append(res, string(key))
+			return true
+		})
+		if fmt.Sprint(res) != fmt.Sprint(test.res) {
+			t.Errorf("test %d: got %q, expected %q", i, res, test.res)
+		}
+	}
+
+	// abort after the first key
+	n := 0
+	if tr.Match(glob("user/*/*"), func([]byte) bool { n++; return false }) || n != 1 {
+		t.Errorf("Match must stop when the handler returns false (%d calls)", n)
+	}
+}
@@ -0,0 +1,44 @@
+package set
+
+import "bytes"
+import "iter"
+
+
+// All returns an iterator over all keys in key order.
+func (t *Set) All() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		t.Iter(nil, yield)
+	}
+}
+
+// Prefix returns an iterator over all keys with a given prefix in key order.
+func (t *Set) Prefix(prefix []byte) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		t.Iter(prefix, yield)
+	}
+}
+
+// Backward returns an iterator over all keys in reverse key order.
+func (t *Set) Backward() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		t.IterReverse(nil, yield)
+	}
+}
+
+// Range returns an iterator over keys in [lo, hi) in key order.
+// A nil bound is unbounded.
+func (t *Set) Range(lo, hi []byte) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		h := yield
+		if hi != nil {
+			h = func(key []byte) bool {
+				return bytes.Compare(key, hi) < 0 && yield(key)
+			}
+		}
+		if lo == nil {
+			t.Iter(nil, h)
+		} else {
+			t.iterateFrom(lo, h)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package set
+
+import "unsafe"
+import "analyzers/lib/critbit/arena"
+
+
+// Stats describes the shape of a tree and estimates its memory usage.
+type Stats struct {
+	// Nodes is the number of internal nodes
+	Nodes    int
+	// Leaves is the number of keys
+	Leaves   int
+	// Depths[d] is the number of leaves d nodes below the root
+	Depths   []int
+	AvgDepth float64
+	MaxDepth int
+	// KeyBytes is the total length of the keys
+	KeyBytes int
+
+	// NodeBytes is the memory taken by the nodes apart from their children
+	NodeBytes    int
+	// RefBytes is the memory taken by the refs (the node children and the root)
+	RefBytes     int
+	// KeyHeapBytes is the memory held by the keys: the arena blocks in the
+	// arena.Copy mode or the capacities of the key slices otherwise
+	KeyHeapBytes int
+}
+
+// HeapBytes returns the estimated memory taken by the tree
+func (s *Stats) HeapBytes() int {
+	return s.NodeBytes + s.RefBytes + s.KeyHeapBytes
+}
+
+// Stats walks the tree and collects its statistics.
+func (t *Set) Stats() (s Stats) {
+	type visit struct {
+		p     *Ref
+		depth int
+	}
+	key_cap, depth_sum := 0, 0
+
+	if ! t.Empty() {
+		// Walk the tree without function recursion
+		to_visit := []visit{{&t.root, 0}}
+
+		for l := len(to_visit); l > 0; l = len(to_visit) {
+			v := to_visit[l-1]
+			to_visit = to_visit[:l-1]
+
+			if n := v.p.node; n != nil {
+				s.Nodes++
+				to_visit = append(to_visit, visit{&n.child[0], v.depth+1}, visit{&n.child[1], v.depth+1})
+				continue
+			}
+			for len(s.Depths) <= v.depth {
+				s.Depths = append(s.Depths, 0)
+			}
+			s.Depths[v.depth]++
+			s.Leaves++
+			s.KeyBytes += len(v.p.Key)
+			s.MaxDepth  = max(s.MaxDepth, v.depth)
+			key_cap    += cap(v.p.Key)
+			depth_sum  += v.depth
+		}
+		s.AvgDepth = float64(depth_sum) / float64(s.Leaves)
+	}
+	ref_size := int(unsafe.Sizeof(Ref{}))
+	s.NodeBytes = s.Nodes * (int(unsafe.Sizeof(Node{})) - 2*ref_size)
+	s.RefBytes  = (2*s.Nodes + 1) * ref_size
+
+	if t.arena.Mode() == arena.Copy {
+		s.KeyHeapBytes = t.arena.Size()
+	} else {
+		s.KeyHeapBytes = key_cap
+	}
+	return
+}
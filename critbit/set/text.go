@@ -0,0 +1,177 @@
+package set
+
+import "io"
+import "bufio"
+import "bytes"
+import "errors"
+import "fmt"
+import "strconv"
+import "encoding/json"
+import "unicode/utf8"
+
+
+// Keys are arbitrary bytes, so the text formats escape them: a backslash
+// becomes \\ while control characters and bytes of invalid UTF-8 sequences
+// become \xNN. Everything else is kept as is.
+
+var errEmptyKey = errors.New("empty key")
+
+// MarshalJSON implements json.Marshaler. A set is an array of keys in key order.
+func (t *Set) MarshalJSON() ([]byte, error) {
+	buf := []byte{'['}
+	t.Iter(nil, func(key []byte) bool {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = appendQuoted(buf, key)
+		return true
+	})
+	return append(buf, ']'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the set content.
+func (t *Set) UnmarshalJSON(data []byte) error {
+	var strs []string
+	if err := json.Unmarshal(data, &strs); err != nil {
+		return err
+	}
+	tmp := Set{arena: t.arena.Fork()}
+	for _, s := range strs {
+		key, err := unescapeKey([]byte(s))
+		if err != nil {
+			return fmt.Errorf("set: key %q: %w", s, err)
+		}
+		tmp.Add(key)
+	}
+	*t = tmp
+	return nil
+}
+
+// WriteTSV writes all keys in key order, one escaped key per line.
+func (t *Set) WriteTSV(w io.Writer) (err error) {
+	bw  := bufio.NewWriter(w)
+	var line []byte
+	t.Iter(nil, func(key []byte) bool {
+		line = append(escapeKey(line[:0], key), '\n')
+		_, err = bw.Write(line)
+		return err == nil
+	})
+	if err == nil {
+		err = bw.Flush()
+	}
+	return
+}
+
+// ReadTSV replaces the set content with keys read from r, one per line.
+func (t *Set) ReadTSV(r io.Reader) error {
+	tmp := Set{arena: t.arena.Fork()}
+	err := readLines(r, func(line []byte) error {
+		if bytes.IndexByte(line, '\t') >= 0 {
+			return errors.New("unexpected value")
+		}
+		key, err := unescapeKey(line)
+		if err == nil {
+			tmp.Add(key)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	*t = tmp
+	return nil
+}
+
+// readLines calls a handler for every line of r without its terminator
+func readLines(r io.Reader, h func([]byte) error) error {
+	br := bufio.NewReader(r)
+	for num := 1; ; num++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return nil
+		}
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if herr := h(line); herr != nil {
+			return fmt.Errorf("set: line %d: %w", num, herr)
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// escapeKey appends an escaped key to dst
+func escapeKey(dst, key []byte) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(key); {
+		c := key[i]
+		n := 1
+		switch {
+		case c == '\\':
+			dst = append(dst, '\\', '\\')
+		case c < 0x20 || c == 0x7f:
+			dst = append(dst, '\\', 'x', hex[c>>4], hex[c&15])
+		case c < utf8.RuneSelf:
+			dst = append(dst, c)
+		default:
+			if _, n = utf8.DecodeRune(key[i:]); n == 1 {
+				dst = append(dst, '\\', 'x', hex[c>>4], hex[c&15])
+			} else {
+				dst = append(dst, key[i:i+n]...)
+			}
+		}
+		i += n
+	}
+	return dst
+}
+
+// unescapeKey returns a new key decoded from an escaped one. Only the
+// escaping of escapeKey is accepted, so distinct strings never decode to the
+// same key.
+func unescapeKey(s []byte) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, errEmptyKey
+	}
+	key := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			key = append(key, c)
+			continue
+		}
+		switch {
+		case i+1 < len(s) && s[i+1] == '\\':
+			key = append(key, '\\')
+			i++
+		case i+3 < len(s) && s[i+1] == 'x':
+			b, err := strconv.ParseUint(string(s[i+2:i+4]), 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("bad escape sequence %q", s[i:i+4])
+			}
+			key = append(key, byte(b))
+			i += 3
+		default:
+			return nil, fmt.Errorf("bad escape sequence at %d", i)
+		}
+	}
+	if ! bytes.Equal(escapeKey(nil, key), s) {
+		return nil, fmt.Errorf("non-canonical escaping of key %q", key)
+	}
+	return key, nil
+}
+
+// appendQuoted appends an escaped key as a JSON string to dst
+func appendQuoted(dst, key []byte) []byte {
+	dst = append(dst, '"')
+	for _, c := range escapeKey(nil, key) {
+		if c == '"' || c == '\\' {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, '"')
+}
@@ -0,0 +1,83 @@
+package set
+
+import "testing"
+import "bytes"
+import "strings"
+import "encoding/json"
+import "analyzers/lib/critbit/arena"
+
+var textKeys = []string{"b", "a\\b", "a\tb", "\xff\x01", "ключ", "a\"b"}
+
+func Test_JSON(t *testing.T) {
+	tr := NewSet()
+	for _, s := range textKeys {
+		tr.Add([]byte(s))
+	}
+	data, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `["a\\x09b","a\"b","a\\\\b","b","ключ","\\xff\\x01"]`
+	if string(data) != expected {
+		t.Errorf("wrong JSON:\n  got %s\n  exp %s", data, expected)
+	}
+	res := NewSet([]byte("stale"))
+	if err := json.Unmarshal(data, res); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if ! testKeysEq(res.Keys(), tr.Keys()) {
+		t.Errorf("round-trip failed: %q", res.Keys())
+	}
+
+	for _, bad := range []string{`[""]`, `["a\\"]`, `["\\x1"]`, `["\\xzz"]`, `["\\n"]`, `{}`, `["\\x41"]`, `["\\x0A"]`} {
+		if err := json.Unmarshal([]byte(bad), res); err == nil {
+			t.Errorf("Unmarshal(%s) must fail", bad)
+		}
+	}
+}
+
+func Test_TSV(t *testing.T) {
+	tr := NewSet()
+	for _, s := range textKeys {
+		tr.Add([]byte(s))
+	}
+	var buf bytes.Buffer
+	if err := tr.WriteTSV(&buf); err != nil {
+		t.Fatalf("WriteTSV failed: %v", err)
+	}
+	expected := "a\\x09b\na\"b\na\\\\b\nb\nключ\n\\xff\\x01\n"
+	if buf.String() != expected {
+		t.Errorf("wrong TSV:\n  got %q\n  exp %q", buf.String(), expected)
+	}
+	res := NewSet()
+	if err := res.ReadTSV(&buf); err != nil {
+		t.Fatalf("ReadTSV failed: %v", err)
+	}
+	if ! testKeysEq(res.Keys(), tr.Keys()) {
+		t.Errorf("round-trip failed: %q", res.Keys())
+	}
+
+	// no trailing newline, CRLF line ends
+	if err := res.ReadTSV(strings.NewReader("x\r\ny")); err != nil || ! testKeysEq(res.Keys(), [][]byte{[]byte("x"), []byte("y")}) {
+		t.Errorf("ReadTSV -> %v, %q", err, res.Keys())
+	}
+	if err := res.ReadTSV(strings.NewReader("a\n\nb\n")); err == nil || ! strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadTSV of an empty line must fail, got %v", err)
+	}
+	if err := res.ReadTSV(strings.NewReader("a\tb\n")); err == nil {
+		t.Error("ReadTSV of a value must fail")
+	}
+	if err := res.ReadTSV(strings.NewReader("\\x61\n")); err == nil {
+		t.Error("ReadTSV of a non-canonical escape must fail")
+	}
+	if res.Len() != 2 {
+		t.Errorf("failed ReadTSV must keep the set intact, got %q", res.Keys())
+	}
+
+	// the key arena starts afresh in the same mode
+	res = NewSet().SetKeyMode(arena.Copy)
+	res.Add([]byte("old"))
+	if res.ReadTSV(strings.NewReader("")) != nil || res.arena.Size() != 0 || res.arena.Mode() != arena.Copy {
+		t.Errorf("ReadTSV must reset the key arena, size %d", res.arena.Size())
+	}
+}
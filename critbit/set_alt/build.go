@@ -0,0 +1,100 @@
+package set
+
+import "fmt"
+import "iter"
+
+
+// BuildSorted builds a set of keys given in increasing order in linear time.
+// It fails on out-of-order and duplicate keys.
+func BuildSorted(keys [][]byte) (*Set, error) {
+	var b builder
+	for _, key := range keys {
+		if err := b.add(key); err != nil {
+			return nil, err
+		}
+	}
+	return b.finish(&Set{}), nil
+}
+
+// BuildSortedSeq is like BuildSorted but takes keys from an iterator.
+func BuildSortedSeq(seq iter.Seq[[]byte]) (*Set, error) {
+	var b builder
+	for key := range seq {
+		if err := b.add(key); err != nil {
+			return nil, err
+		}
+	}
+	return b.finish(&Set{}), nil
+}
+
+
+// builder assembles a tree bottom-up from keys coming in increasing order.
+// It keeps the right spine of the tree built so far, so that every key is
+// linked in amortized O(1) time. Subtree sizes are computed by finish().
+type builder struct {
+	root  Ref
+	// spine holds the Refs on the right edge of the tree (from the root down)
+	spine []*Ref
+	size  int
+}
+
+// add appends a key which must be greater than all keys added before.
+func (b *builder) add(key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("set: empty key at position %d", b.size)
+	}
+	if b.size == 0 {
+		b.root.Key = key
+		b.spine = append(b.spine[:0], &b.root)
+		b.size++
+		return nil
+	}
+	last := b.spine[len(b.spine)-1]
+	off, num, ldir, differ := critbit(key, last.Key)
+	if ! differ {
+		return fmt.Errorf("set: duplicate key %q at position %d", key, b.size)
+	}
+	if ldir == 1 {
+		return fmt.Errorf("set: key %q at position %d is less than the previous key %q", key, b.size, last.Key)
+	}
+	// ascend the spine while the nodes are below the new crit bit
+	i := len(b.spine) - 1
+	for i > 0 {
+		n := b.spine[i-1].node
+		byteoff := n.bitoff >> 3
+		bitnum  := byte(n.bitoff) & 7
+		if byteoff < off || byteoff == off && bitnum > num {
+			break
+		}
+		i--
+	}
+	// insert new node (the new key always goes right)
+	wp := b.spine[i]
+	nn := &Node{bitoff: (off << 3) + uint(num)}
+	nn.child[0] = *wp
+	nn.child[1].Key = key
+	*wp = Ref{node: nn}
+
+	b.spine = append(b.spine[:i+1], &nn.child[1])
+	b.size++
+	return nil
+}
+
+// finish computes subtree sizes, moves the tree into a Set and returns it.
+func (b *builder) finish(t *Set) *Set {
+	sumSizes(&b.root)
+	t.root = b.root
+	t.size = b.size
+	*b = builder{}
+	return t
+}
+
+// sumSizes sets subtree sizes of all nodes under the Ref and returns the count
+func sumSizes(ref *Ref) int {
+	if ref.node == nil {
+		return 1
+	}
+	n := ref.node
+	n.size = sumSizes(&n.child[0]) + sumSizes(&n.child[1])
+	return n.size
+}
@@ -0,0 +1,54 @@
+package set
+
+import "testing"
+import "strings"
+
+func Test_BuildSorted(t *testing.T) {
+	orig_keys := []string{"aa", "aaa", "aab", "ab", "b", "ba", "bb", "bba", "bbb", "c\x00d"}
+	var keys [][]byte
+	for _, s := range orig_keys {
+		keys = append(keys, []byte(s))
+	}
+	tr, err := BuildSorted(keys)
+	if err != nil {
+		t.Fatalf("BuildSorted failed: %v", err)
+	}
+	if ! testKeysEq(tr.Keys(), keys) || tr.Len() != len(keys) {
+		t.Errorf("Got: %q", tr.Keys())
+	}
+	for _, key := range keys {
+		if ! tr.Has(key) {
+			t.Errorf("key %q is missing", key)
+		}
+	}
+	// the tree must be the same as the one built by insertions
+	if ! testKeysEq(NewSet(keys...).KeysReverse(), tr.KeysReverse()) {
+		t.Errorf("Got: %q", tr.KeysReverse())
+	}
+	seq, err := BuildSortedSeq(tr.All())
+	if err != nil || ! testKeysEq(seq.Keys(), keys) {
+		t.Errorf("BuildSortedSeq -> %q, %v", seq.Keys(), err)
+	}
+	if tr, err := BuildSorted(nil); err != nil || ! tr.Empty() {
+		t.Errorf("BuildSorted(nil) -> %q, %v", tr.Keys(), err)
+	}
+
+	tests := []struct {
+		keys []string
+		err  string
+	}{
+		{[]string{"a", "c", "b"}, "less than the previous key"},
+		{[]string{"a", "b", "b"}, "duplicate key"},
+		{[]string{"a", "a\x00"}, "duplicate key"},
+		{[]string{"a", ""}, "empty key"},
+	}
+	for i, test := range tests {
+		var keys [][]byte
+		for _, s := range test.keys {
+			keys = append(keys, []byte(s))
+		}
+		if _, err := BuildSorted(keys); err == nil || ! strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected %q error, got %v", i, test.err, err)
+		}
+	}
+}
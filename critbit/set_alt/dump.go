@@ -0,0 +1,99 @@
+package set
+
+import "io"
+import "fmt"
+import "strings"
+
+
+// DumpOpts controls the output of Dump
+type DumpOpts struct {
+	// MaxDepth limits the number of levels printed below the root (0 means no limit)
+	MaxDepth int
+}
+
+// Dump writes a text rendering of the tree: a line per ref with the children
+// of a node indented below it (L: left, R: right). A leaf shows its byte at
+// the offset of the parent node. The output only depends on the structure of
+// the tree, so it is stable enough for golden tests.
+func (t *Set) Dump(w io.Writer, opts DumpOpts) error {
+	d := dumper{w: w}
+	if t.Empty() {
+		d.printf("T: EMPTY\n")
+	} else {
+		t.dump(&d, &t.root, "T:", 0, 0, opts)
+	}
+	return d.err
+}
+
+func (t *Set) dump(d *dumper, ref *Ref, tag string, off, depth int, opts DumpOpts) {
+	indent := strings.Repeat("  ", depth)
+	if ref.node == nil {
+		critbyte := "     [        ]"
+		if off < len(ref.Key) {
+			critbyte = fmt.Sprintf("0x%02x [%08b]", ref.Key[off], ref.Key[off])
+		}
+		d.printf("%s%s LEAF byte=%s key=%q\n", indent, tag, critbyte, ref.Key)
+		return
+	}
+	n := ref.node
+	off, bit := int(n.bitoff >> 3), byte(1) << (n.bitoff & 7)
+	d.printf("%s%s NODE off=%v mask=%08b\n", indent, tag, off, bit)
+
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		d.printf("%s  ...\n", indent)
+		return
+	}
+	t.dump(d, &n.child[0], "L:", off, depth+1, opts)
+	t.dump(d, &n.child[1], "R:", off, depth+1, opts)
+}
+
+// WriteDOT writes a Graphviz rendering of the tree. Nodes are labeled with
+// their crit-byte offsets and masks, edges with the directions.
+func (t *Set) WriteDOT(w io.Writer) error {
+	d := dumper{w: w}
+	d.printf("digraph set {\n\tnode [fontname=\"monospace\"];\n")
+	if ! t.Empty() {
+		id := 0
+		t.dot(&d, &t.root, &id)
+	}
+	d.printf("}\n")
+	return d.err
+}
+
+// dot writes a ref with its subtree and returns its id (refs are numbered
+// in the preorder)
+func (t *Set) dot(d *dumper, ref *Ref, next_id *int) int {
+	id := *next_id
+	*next_id++
+
+	if ref.node == nil {
+		d.printf("\tn%d [shape=box, label=%s];\n", id, dotQuote(fmt.Sprintf("%q", ref.Key)))
+		return id
+	}
+	n := ref.node
+	d.printf("\tn%d [shape=ellipse, label=%s];\n", id, dotQuote(fmt.Sprintf("off=%v mask=%08b", n.bitoff >> 3, byte(1) << (n.bitoff & 7))))
+
+	for dir := 0; dir < 2; dir++ {
+		child := t.dot(d, &n.child[dir], next_id)
+		d.printf("\tn%d -> n%d [label=\"%d\"];\n", id, child, dir)
+	}
+	return id
+}
+
+// dumper writes formatted output remembering the first error
+type dumper struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumper) printf(format string, args ...any) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+// dotQuote returns a DOT string literal (newlines become line breaks)
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
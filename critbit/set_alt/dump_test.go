@@ -0,0 +1,56 @@
+package set
+
+import "testing"
+import "bytes"
+
+func Test_Dump(t *testing.T) {
+	tr := NewSet([]byte("a"), []byte("b"), []byte("c\x00"))
+
+	tests := []struct {
+		opts DumpOpts
+		exp  string
+	}{
+		{DumpOpts{},
+			"T: NODE off=0 mask=00000010\n" +
+			"  L: LEAF byte=0x61 [01100001] key=\"a\"\n" +
+			"  R: NODE off=0 mask=00000001\n" +
+			"    L: LEAF byte=0x62 [01100010] key=\"b\"\n" +
+			"    R: LEAF byte=0x63 [01100011] key=\"c\\x00\"\n"},
+		{DumpOpts{MaxDepth: 1},
+			"T: NODE off=0 mask=00000010\n" +
+			"  L: LEAF byte=0x61 [01100001] key=\"a\"\n" +
+			"  R: NODE off=0 mask=00000001\n" +
+			"    ...\n"},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		if err := tr.Dump(&buf, test.opts); err != nil || buf.String() != test.exp {
+			t.Errorf("test %d: got (%v)\n%s", i, err, buf.String())
+		}
+	}
+	var buf bytes.Buffer
+	if NewSet().Dump(&buf, DumpOpts{}); buf.String() != "T: EMPTY\n" {
+		t.Errorf("got %q for an empty set", buf.String())
+	}
+}
+
+func Test_WriteDOT(t *testing.T) {
+	tr := NewSet([]byte("a"), []byte("b"), []byte("c\x00"))
+	exp := `digraph set {
+	node [fontname="monospace"];
+	n0 [shape=ellipse, label="off=0 mask=00000010"];
+	n1 [shape=box, label="\"a\""];
+	n0 -> n1 [label="0"];
+	n2 [shape=ellipse, label="off=0 mask=00000001"];
+	n3 [shape=box, label="\"b\""];
+	n2 -> n3 [label="0"];
+	n4 [shape=box, label="\"c\\x00\""];
+	n2 -> n4 [label="1"];
+	n0 -> n2 [label="1"];
+}
+`
+	var buf bytes.Buffer
+	if err := tr.WriteDOT(&buf); err != nil || buf.String() != exp {
+		t.Errorf("got (%v)\n%s", err, buf.String())
+	}
+}
@@ -0,0 +1,51 @@
+package set
+
+import "testing"
+import "analyzers/lib/critbit/arena"
+
+func Test_KeyModes(t *testing.T) {
+	buf := []byte("aa")
+
+	// borrowed keys are corrupted by buffer reuse
+	tr := NewSet()
+	tr.Add(buf)
+	copy(buf, "bb")
+	if tr.Has([]byte("aa")) {
+		t.Error("a borrowed key must reflect buffer changes")
+	}
+
+	tr = NewSet().SetKeyMode(arena.Copy)
+	for _, s := range []string{"aa", "ab", "ba"} {
+		copy(buf, s)
+		tr.Add(buf)
+	}
+	copy(buf, "AA")
+	if keys := tr.Keys(); len(keys) != 3 || string(keys[0]) != "aa" || string(keys[2]) != "ba" {
+		t.Errorf("copied keys must not change, got %q", keys)
+	}
+
+	tr = NewSet().SetKeyMode(arena.Debug)
+	copy(buf, "aa")
+	tr.Add(buf)
+	tr.AddCopy([]byte("zz"))
+	if err := tr.CheckKeys(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	buf[0] = 'x'
+	if err := tr.CheckKeys(); err == nil {
+		t.Error("CheckKeys must detect a modified key")
+	}
+	tr.Compact()
+	if err := tr.CheckKeys(); err == nil || string(tr.Keys()[0]) != "xa" {
+		t.Errorf("Compact must keep the borrowed keys, got %q, %v", tr.Keys(), err)
+	}
+
+	tr = NewSet().SetKeyMode(arena.Copy)
+	copy(buf, "aa")
+	tr.Add(buf)
+	tr.Compact()
+	copy(buf, "bb")
+	if ! tr.Has([]byte("aa")) {
+		t.Error("Compact must keep the copied keys")
+	}
+}
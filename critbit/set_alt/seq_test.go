@@ -0,0 +1,61 @@
+package set
+
+import "testing"
+
+func seqKeys(seq func(func([]byte) bool)) (s []string) {
+	for key := range seq {
+		s = append(s, string(key))
+	}
+	return
+}
+
+func Test_Seq(t *testing.T) {
+	tr := NewSet()
+	for _, s := range []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"} {
+		tr.Add([]byte(s))
+	}
+	tests := []struct {
+		name string
+		seq  func(func([]byte) bool)
+		keys []string
+	}{
+		{"All",        tr.All(),                 []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}},
+		{"Prefix(a)",  tr.Prefix([]byte("a")),   []string{"aa", "aaa", "aab", "ab"}},
+		{"Prefix(c)",  tr.Prefix([]byte("c")),   nil},
+		{"Backward",   tr.Backward(),            []string{"bbb", "bba", "bb", "ba", "ab", "aab", "aaa", "aa"}},
+		{"Range(-,-)", tr.Range(nil, nil),       []string{"aa", "aaa", "aab", "ab", "ba", "bb", "bba", "bbb"}},
+		{"Range(aab,bb)", tr.Range([]byte("aab"), []byte("bb")), []string{"aab", "ab", "ba"}},
+		{"Range(aac,-)",  tr.Range([]byte("aac"), nil),          []string{"ab", "ba", "bb", "bba", "bbb"}},
+		{"Range(a,aab)",  tr.Range([]byte("a"), []byte("aab")),  []string{"aa", "aaa"}},
+		{"Range(b,-)",    tr.Range([]byte("b"), nil),            []string{"ba", "bb", "bba", "bbb"}},
+		{"Range(bbba,-)", tr.Range([]byte("bbba"), nil),         nil},
+		{"Range(-,a)",    tr.Range(nil, []byte("a")),            nil},
+		{"Range(0,-)",    tr.Range([]byte("0"), []byte("ab")),   []string{"aa", "aaa", "aab"}},
+	}
+	for _, test := range tests {
+		got := seqKeys(test.seq)
+		if len(got) != len(test.keys) {
+			t.Errorf("%s: got %q, expected %q", test.name, got, test.keys)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.keys[i] {
+				t.Errorf("%s: got %q, expected %q", test.name, got, test.keys)
+				break
+			}
+		}
+	}
+}
+
+func Test_SeqBreak(t *testing.T) {
+	tr := NewSet([]byte("a"), []byte("b"), []byte("c"))
+	n := 0
+	for range tr.Range([]byte("a"), nil) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("the loop must stop after a break, got %d iterations", n)
+	}
+}
@@ -1,6 +1,6 @@
 package set
 
-import "fmt"
+import "analyzers/lib/critbit/arena"
 
 
 // Ref holds either a Key or a Node pointer
@@ -9,8 +9,18 @@ type Ref struct {
 	node *Node
 }
 
+// count returns the number of leaves under the Ref
+func (ref *Ref) count() int {
+	if ref.node != nil {
+		return ref.node.size
+	}
+	return 1
+}
+
 type Node struct {
 	child  [2]Ref
+	// size is the number of leaves in the subtree
+	size   int
 	// bitoff is a number of critical bit counting from the start of bit string
 	bitoff uint
 }
@@ -19,6 +29,8 @@ type Node struct {
 type Set struct {
 	size int
 	root Ref
+	// arena stores the keys (the zero value stores them as passed)
+	arena arena.Keys
 }
 
 // dir calculates the direction for the given key
@@ -32,7 +44,7 @@ func (n *Node) dir(key []byte) byte {
 }
 
 func InitSet(set *Set, keys ...[]byte) *Set {
-	*set = Set{}
+	*set = Set{arena: set.arena}
 	for _, key := range keys {
 		set.Add(key)
 	}
@@ -80,9 +92,14 @@ func (t *Set) Has(key []byte) bool {
 
 // Set associates a given count with a key. Returns previous count.
 func (t *Set) Add(key []byte) bool {
+	return t.add(key, false)
+}
+
+// add inserts a key storing a copy of it if necessary
+func (t *Set) add(key []byte, copy bool) bool {
 	// test for empty tree
 	if t.Empty() {
-		t.root.Key = key
+		t.root.Key = t.arena.Store(key, copy)
 		t.size++
 		return true
 	}
@@ -127,7 +144,7 @@ ByteFound:
 	}
 	// insert new node
 	nn := Node{bitoff: (off << 3) + uint(num)}
-	nn.child[1-ndir].Key = key
+	nn.child[1-ndir].Key = t.arena.Store(key, copy)
 
 	// walk for best insertion node
 	wp := &t.root
@@ -138,10 +155,12 @@ ByteFound:
 		if byteoff > off || byteoff == off && bitnum < num {
 			break
 		}
+		n.size++
 		// try next node
 		wp = &n.child[n.dir(key)]
 	}
 	nn.child[ndir] = *wp
+	nn.size = wp.count() + 1
 	wp.node = &nn
 	wp.Key  = nil
 	t.size++
@@ -177,14 +196,39 @@ func (t *Set) Del(key []byte) bool {
 	}
 	// delete from the tree
 	t.size--
+	t.arena.Release(p.Key)
 	if wp == nil {
 		t.root = Ref{}
 		return true
 	}
+	t.resize(key, wp, -1)
 	*wp = wp.node.child[1-dir]
 	return true
 }
 
+// DeletePrefix removes all keys with a given prefix and returns their number.
+// The whole subtree is unlinked at once and the number comes from its size,
+// so it takes O(depth) time (plus O(k) for k removed keys in the
+// arena.Debug mode).
+func (t *Set) DeletePrefix(prefix []byte) int {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return 0
+	}
+	n := top.count()
+	t.size -= n
+	if t.arena.Mode() == arena.Debug {
+		// only the debug mode keeps track of the stored keys
+		t.iterate(*top, 0, func(key []byte) bool {
+			t.arena.Release(key)
+			return true
+		})
+	}
+	t.resize(prefix, parent, -n)
+	t.unlink(top, parent)
+	return n
+}
+
 // Merge merges another Set into this one. Returns itself.
 func (t *Set) Merge(other *Set, prefix []byte) *Set {
 	if other != nil {
@@ -197,52 +241,203 @@ func (t *Set) Merge(other *Set, prefix []byte) *Set {
 	return t
 }
 
-// Iter calls a handler for all keys with a given prefix.
-// It returns whether all prefixed keys were iterated.
-// The handler can continue the process by returning true or abort with false.
-func (t *Set) Iter(prefix []byte, handler func([]byte) bool) bool {
+// Min returns the smallest key with a given prefix.
+func (t *Set) Min(prefix []byte) (key []byte, ok bool) {
+	if leaf, _ := t.edge(prefix, 0); leaf != nil {
+		return leaf.Key, true
+	}
+	return
+}
+
+// Max returns the greatest key with a given prefix.
+func (t *Set) Max(prefix []byte) (key []byte, ok bool) {
+	if leaf, _ := t.edge(prefix, 1); leaf != nil {
+		return leaf.Key, true
+	}
+	return
+}
+
+// PopMin removes the smallest key with a given prefix and returns it.
+func (t *Set) PopMin(prefix []byte) (key []byte, ok bool) {
+	return t.pop(prefix, 0)
+}
+
+// PopMax removes the greatest key with a given prefix and returns it.
+func (t *Set) PopMax(prefix []byte) (key []byte, ok bool) {
+	return t.pop(prefix, 1)
+}
+
+// pop removes the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix.
+func (t *Set) pop(prefix []byte, dir byte) (key []byte, ok bool) {
+	leaf, parent := t.edge(prefix, dir)
+	if leaf == nil {
+		return
+	}
+	key, ok = leaf.Key, true
+	// delete from the tree
+	t.size--
+	t.arena.Release(key)
+	t.resize(key, parent, -1)
+	t.unlink(leaf, parent)
+	return
+}
+
+// edge finds the leftmost (dir=0) or rightmost (dir=1) leaf with a given prefix
+// along with the Ref of its parent node.
+func (t *Set) edge(prefix []byte, dir byte) (leaf, parent *Ref) {
+	top, parent := t.findTop(prefix)
+	if top == nil {
+		return nil, nil
+	}
+	leaf = top
+	for leaf.node != nil {
+		parent = leaf
+		leaf = &leaf.node.child[dir]
+	}
+	return
+}
+
+// resize adds delta to subtree sizes of the nodes on the path of key
+// above the stop Ref
+func (t *Set) resize(key []byte, stop *Ref, delta int) {
+	if stop == nil {
+		return
+	}
+	for p := &t.root; p != stop; p = &p.node.child[p.node.dir(key)] {
+		p.node.size += delta
+	}
+}
+
+// unlink removes the subtree at ref from its parent node (nil for the root).
+func (t *Set) unlink(ref, parent *Ref) {
+	if parent == nil {
+		t.root = Ref{}
+		return
+	}
+	if &parent.node.child[0] == ref {
+		*parent = parent.node.child[1]
+	} else {
+		*parent = parent.node.child[0]
+	}
+}
+
+// findTop returns the topmost Ref holding all keys with a given prefix
+// (nil if there are no such keys) along with the Ref of its parent node.
+func (t *Set) findTop(prefix []byte) (top, parent *Ref) {
 	// test empty tree
 	if t.Empty() {
-		return true
+		return
 	}
-	// shortcut for empty prefix
+	// walk for best member
 	var lpre = len(prefix)
 
-	if lpre == 0 {
-		return t.iterate(t.root, handler)
-	}
-	// walk for best member
-	p, top := t.root, t.root
+	p := &t.root
+	top = p
 	for p.node != nil {
 		byteoff := int(p.node.bitoff >> 3)
 		newtop  := byteoff < lpre
+		wp := p
 		// try next node
-		p = p.node.child[p.node.dir(prefix)]
+		p = &p.node.child[p.node.dir(prefix)]
 		if newtop {
-			top = p
+			top, parent = p, wp
 		}
 	}
-	if len(p.Key) < lpre {
-		return true
+	if len(p.Key) < len(prefix) {
+		return nil, nil
 	}
-	for i := 0; i < lpre; i++ {
+	for i := 0; i < len(prefix); i++ {
 		if p.Key[i] != prefix[i] {
-			return true
+			return nil, nil
 		}
 	}
-	return t.iterate(top, handler)
+	return
+}
+
+// Iter calls a handler for all keys with a given prefix.
+// It returns whether all prefixed keys were iterated.
+// The handler can continue the process by returning true or abort with false.
+func (t *Set) Iter(prefix []byte, handler func([]byte) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 0, handler)
+	}
+	return true
+}
+
+// IterReverse is like Iter but calls the handler in a reverse key order.
+func (t *Set) IterReverse(prefix []byte, handler func([]byte) bool) bool {
+	if top, _ := t.findTop(prefix); top != nil {
+		return t.iterate(*top, 1, handler)
+	}
+	return true
 }
 
 // iterate calls the key handler or traverses both node children unless aborted.
-func (t *Set) iterate(p Ref, h func([]byte) bool) bool {
+// The children are visited in key order (rev=0) or in reverse order (rev=1).
+func (t *Set) iterate(p Ref, rev byte, h func([]byte) bool) bool {
 	if p.node != nil {
-		return t.iterate(p.node.child[0], h) && t.iterate(p.node.child[1], h)
+		return t.iterate(p.node.child[rev], rev, h) && t.iterate(p.node.child[1-rev], rev, h)
 	}
 	return h(p.Key)
 }
 
+// iterateFrom calls the key handler for all keys greater-or-equal to lo in key order.
+// It descends once along lo collecting the right siblings to visit afterwards.
+func (t *Set) iterateFrom(lo []byte, h func([]byte) bool) bool {
+	// test empty tree
+	if t.Empty() {
+		return true
+	}
+	// walk for best member
+	p := t.root
+	for p.node != nil {
+		// try next node
+		p = p.node.child[p.node.dir(lo)]
+	}
+	off, num, pdir, differ := critbit(lo, p.Key)
+
+	// walk down to the insertion point of lo remembering greater siblings
+	var rest []Ref
+	wp := t.root
+	for wp.node != nil {
+		n := wp.node
+		byteoff := n.bitoff >> 3
+		bitnum  := byte(n.bitoff) & 7
+		if differ && (byteoff > off || byteoff == off && bitnum < num) {
+			break
+		}
+		dir := n.dir(lo)
+		if dir == 0 {
+			rest = append(rest, n.child[1])
+		}
+		wp = n.child[dir]
+	}
+	// the subtree at the insertion point is either all greater or all lesser
+	if ! differ || pdir == 1 {
+		if ! t.iterate(wp, 0, h) {
+			return false
+		}
+	}
+	for i := len(rest) - 1; i >= 0; i-- {
+		if ! t.iterate(rest[i], 0, h) {
+			return false
+		}
+	}
+	return true
+}
+
 // Keys returns all keys, as a slice of []byte, in a sorted order.
 func (t *Set) Keys() [][]byte {
+	return t.keys(0)
+}
+
+// KeysReverse returns all keys, as a slice of []byte, in a reverse sorted order.
+func (t *Set) KeysReverse() [][]byte {
+	return t.keys(1)
+}
+
+// keys collects all keys in key order (rev=0) or in reverse order (rev=1).
+func (t *Set) keys(rev byte) [][]byte {
 	keys := make([][]byte, 0, t.size)
 
 	// empty tree?
@@ -268,36 +463,47 @@ func (t *Set) Keys() [][]byte {
 			keys = append(keys, p.Key)
 		} else {
 			// unshift the children and continue
-			to_visit = append(to_visit, &p.node.child[1], &p.node.child[0])
+			to_visit = append(to_visit, &p.node.child[1-rev], &p.node.child[rev])
 		}
 	}
 	return keys
 }
 
-func (t *Set) debug_dump(node *Node, indent string) {
-	bytenum := node.bitoff >> 3
-	bitnum  := node.bitoff  & 7
-	fmt.Printf("%sN: bytenum=%v bitnum=%v\n", indent, bytenum, bitnum)
-
-	left := node.child[0]
-	if bytenum < uint(len(left.Key)) {
-		fmt.Printf("%sL: byte=%b key=%s\n", indent, left.Key[bytenum], left.Key)
-	} else {
-		fmt.Printf("%sL:\n", indent)
-	}
-	if node.child[0].node != nil {
-		t.debug_dump(node.child[0].node, indent + "  ")
+// critbit finds the first bit the key differs from another key in.
+// It returns the offset of the differing byte, the number of the bit in it
+// and the direction of the other key. differ is false if the keys are equal.
+func critbit(key, other []byte) (off uint, num, odir byte, differ bool) {
+	var ch, bit byte
+	var klen = uint(len(key))
+	var olen = uint(len(other))
+	// find differing byte
+	for off = 0; off < klen; off++ {
+		if ch = 0; off < olen {
+			ch = other[off]
+		}
+		if keych := key[off]; ch != keych {
+			bit = ch ^ keych
+			goto ByteFound
+		}
 	}
-
-	right := node.child[1]
-	if bytenum < uint(len(right.Key)) {
-		fmt.Printf("%sR: byte=%b key=%s\n", indent, right.Key[bytenum], right.Key)
-	} else {
-		fmt.Printf("%sR:\n", indent)
+	if off < olen {
+		ch = other[off]
+		bit = ch
+		goto ByteFound
 	}
-	if node.child[1].node != nil {
-		t.debug_dump(node.child[1].node, indent + "  ")
+	return
+ByteFound:
+	// find differing bit
+	bit |= bit >> 1
+	bit |= bit >> 2
+	bit |= bit >> 4
+	num = popcount(bit >> 1)  // 0001 1111 -> 4
+	bit = bit &^ (bit >> 1)   // 0001 1111 -> 0001 0000
+	if ch & bit != 0 {
+		odir = 1
 	}
+	differ = bit != 0
+	return
 }
 
 func popcount(x byte) byte {
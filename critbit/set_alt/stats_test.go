@@ -0,0 +1,25 @@
+package set
+
+import "testing"
+import "slices"
+
+func Test_Stats(t *testing.T) {
+	s := NewSet().Stats()
+	if s.Nodes != 0 || s.Leaves != 0 || len(s.Depths) != 0 || s.KeyBytes != 0 || s.HeapBytes() != s.RefBytes {
+		t.Errorf("wrong stats of an empty set: %+v", s)
+	}
+
+	// 'a' differs from 'b' and 'c' in the 0x02 bit, 'b' and 'c' differ in the 0x01 bit
+	tr := NewSet([]byte("a"), []byte("b"), []byte("c"))
+	s = tr.Stats()
+	if s.Nodes != 2 || s.Leaves != 3 || s.KeyBytes != 3 {
+		t.Errorf("wrong counts: %+v", s)
+	}
+	if ! slices.Equal(s.Depths, []int{0, 1, 2}) || s.MaxDepth != 2 || s.AvgDepth != 5.0/3 {
+		t.Errorf("wrong depths: %+v", s)
+	}
+	if s.NodeBytes <= 0 || s.RefBytes <= 0 || s.KeyHeapBytes < s.KeyBytes {
+		t.Errorf("wrong memory estimates: %+v", s)
+	}
+
+}
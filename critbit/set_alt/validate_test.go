@@ -0,0 +1,36 @@
+package set
+
+import "testing"
+import "strings"
+
+func Test_Validate(t *testing.T) {
+	newSet := func() *Set {
+		return NewSet([]byte("abc"), []byte("abd"), []byte("b"), []byte("ba"))
+	}
+	for _, tr := range []*Set{NewSet(), NewSet([]byte("a")), newSet()} {
+		if err := tr.Validate(); err != nil {
+			t.Errorf("%q: unexpected error %v", tr.Keys(), err)
+		}
+	}
+
+	tests := []struct {
+		corrupt func(*Set)
+		err     string
+	}{
+		// a key buffer reused after insertion
+		{func(tr *Set) { tr.root.node.child[0].node.child[1].Key[2] = 'a' }, "not greater than the previous key"},
+		{func(tr *Set) { tr.root.node.child[0].node.child[1].Key[1] = 'c' }, "instead of the crit bit"},
+		{func(tr *Set) { c := &tr.root.node.child; c[0], c[1] = c[1], c[0] }, "wrong side"},
+		{func(tr *Set) { tr.size++ }, "size is 5 but there are 4 keys"},
+		{func(tr *Set) { tr.root.node.child[0].node.bitoff = 1 }, "not below its parent"},
+		{func(tr *Set) { tr.root.node.child[1].node.child[1].Key = []byte("b") }, "not greater than the previous key"},
+		{func(tr *Set) { tr.root.node.child[0].node.child[0].Key = nil }, "empty key"},
+	}
+	for i, test := range tests {
+		tr := newSet()
+		test.corrupt(tr)
+		if err := tr.Validate(); err == nil || ! strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected %q error, got %v", i, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package set
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpOpts controls the output of Dump
+type DumpOpts struct {
+	MaxDepth	int		// number of levels printed below the root (0 means no limit)
+}
+
+// Dump writes a text rendering of the set: a line per node with its children
+// indented below it. A node lists the bytes present at its level, a leaf lists
+// the values. Every child line starts with the byte leading to it.
+func (t *Set) Dump(w io.Writer, opts DumpOpts) error {
+	d := dumper{w: w}
+	if t == nil || t.root == nil {
+		d.printf("T: EMPTY\n")
+	} else {
+		t.dump(&d, t.root, "T:", 0, 0, opts)
+	}
+	return d.err
+}
+
+func (t *Set) dump(d *dumper, node *Node, tag string, prefix uint64, depth int, opts DumpOpts) {
+	indent := strings.Repeat("  ", depth)
+	bytes  := node.bytes()
+
+	if depth == 7 {
+		values := make([]string, len(bytes))
+		for i, b := range bytes {
+			values[i] = fmt.Sprintf("%#x", prefix << 8 | uint64(b))
+		}
+		d.printf("%s%s LEAF values=[%s]\n", indent, tag, strings.Join(values, " "))
+		return
+	}
+	d.printf("%s%s NODE bytes=[% x]\n", indent, tag, bytes)
+
+	if len(bytes) > 0 && opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		d.printf("%s  ...\n", indent)
+		return
+	}
+	for i, b := range bytes {
+		t.dump(d, node.children[i], fmt.Sprintf("%02x:", b), prefix << 8 | uint64(b), depth+1, opts)
+	}
+}
+
+// WriteDOT writes a Graphviz rendering of the set. Edges are labeled with
+// the bytes leading to the nodes.
+func (t *Set) WriteDOT(w io.Writer) error {
+	d := dumper{w: w}
+	d.printf("digraph veb {\n\tnode [fontname=\"monospace\"];\n")
+	if t != nil && t.root != nil {
+		id := 0
+		t.dot(&d, t.root, 0, &id)
+	}
+	d.printf("}\n")
+	return d.err
+}
+
+// dot writes a node with its subtree and returns its id (nodes are numbered
+// in the preorder)
+func (t *Set) dot(d *dumper, node *Node, depth int, next_id *int) int {
+	id    := *next_id
+	bytes := node.bytes()
+	*next_id++
+
+	if depth == 7 {
+		d.printf("\tn%d [shape=box, label=\"% x\"];\n", id, bytes)
+		return id
+	}
+	d.printf("\tn%d [shape=ellipse, label=\"level %d\"];\n", id, depth)
+
+	for i, b := range bytes {
+		child := t.dot(d, node.children[i], depth+1, next_id)
+		d.printf("\tn%d -> n%d [label=\"%02x\"];\n", id, child, b)
+	}
+	return id
+}
+
+// bytes returns the bytes set in the bitmap of a node (in ascending order)
+func (n *Node) bytes() []byte {
+	var res []byte
+	for i := 0; i < 256; i++ {
+		if (n.bitmap[i >> 6] >> (i & 0x3F)) & 0x01 != 0 {
+			res = append(res, byte(i))
+		}
+	}
+	return res
+}
+
+// dumper writes formatted output remembering the first error
+type dumper struct {
+	w	io.Writer
+	err	error
+}
+
+func (d *dumper) printf(format string, args ...any) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
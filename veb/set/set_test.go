@@ -1,6 +1,10 @@
 package set
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
 
 func Test_EmptySetHas(t *testing.T) {
 	s := NewSet()
@@ -92,3 +96,87 @@ func Test_SetAdd(t *testing.T) {
 		t.Errorf("s.size is not 3 as expected, instead: %v", s.size)
 	}
 }
+
+func Test_Stats(t *testing.T) {
+	s := NewSet()
+
+	st := s.Stats()
+	if st.Nodes != 1 || st.Leaves != 0 || st.KeyBytes != 0 {
+		t.Errorf("wrong stats of an empty set: %+v", st)
+	}
+
+	// 0 and 1 share a leaf, 256 shares all nodes above it
+	s.Add(0)
+	s.Add(1)
+	s.Add(256)
+	s.Add(1 << 56)
+
+	st = s.Stats()
+	if st.Nodes != 1 + 2*6 + 3 || st.Leaves != 3 {
+		t.Errorf("wrong number of nodes: %+v", st)
+	}
+	if len(st.Depths) != 8 || st.Depths[0] != 1 || st.Depths[1] != 2 || st.Depths[7] != 3 {
+		t.Errorf("wrong depths: %v", st.Depths)
+	}
+	if st.MaxDepth != 7 || st.AvgDepth != 7 || st.KeyBytes != 32 {
+		t.Errorf("wrong stats: %+v", st)
+	}
+	if st.NodeBytes <= 0 || st.RefBytes <= 0 || st.HeapBytes() != st.NodeBytes + st.RefBytes {
+		t.Errorf("wrong memory estimates: %+v", st)
+	}
+}
+
+func Test_Dump(t *testing.T) {
+	s := NewSet()
+	s.Add(0)
+	s.Add(1)
+	s.Add(256)
+
+	exp := "T: NODE bytes=[00]\n" +
+		"  00: NODE bytes=[00]\n" +
+		"    00: NODE bytes=[00]\n" +
+		"      00: NODE bytes=[00]\n" +
+		"        00: NODE bytes=[00]\n" +
+		"          00: NODE bytes=[00]\n" +
+		"            00: NODE bytes=[00 01]\n" +
+		"              00: LEAF values=[0x0 0x1]\n" +
+		"              01: LEAF values=[0x100]\n"
+	var buf bytes.Buffer
+	if err := s.Dump(&buf, DumpOpts{}); err != nil || buf.String() != exp {
+		t.Errorf("got (%v)\n%s", err, buf.String())
+	}
+
+	exp = "T: NODE bytes=[00]\n" +
+		"  00: NODE bytes=[00]\n" +
+		"    ...\n"
+	buf.Reset()
+	if err := s.Dump(&buf, DumpOpts{MaxDepth: 1}); err != nil || buf.String() != exp {
+		t.Errorf("got (%v)\n%s", err, buf.String())
+	}
+}
+
+func Test_WriteDOT(t *testing.T) {
+	s := NewSet()
+	s.Add(0)
+	s.Add(1)
+	s.Add(256)
+
+	var buf bytes.Buffer
+	if err := s.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"digraph veb {\n",
+		"\tn0 [shape=ellipse, label=\"level 0\"];\n",
+		"\tn7 [shape=box, label=\"00 01\"];\n",
+		"\tn6 -> n7 [label=\"00\"];\n",
+		"\tn8 [shape=box, label=\"00\"];\n",
+		"\tn6 -> n8 [label=\"01\"];\n",
+		"\tn0 -> n1 [label=\"00\"];\n",
+	} {
+		if ! strings.Contains(out, line) {
+			t.Errorf("%q is missing in\n%s", line, out)
+		}
+	}
+}
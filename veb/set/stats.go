@@ -0,0 +1,53 @@
+package set
+
+import (
+	"unsafe"
+)
+
+// Stats describes the shape of a set and estimates its memory usage.
+// Values are bits of the leaf bitmaps, so they take no memory of their own.
+type Stats struct {
+	Nodes		int			// number of nodes
+	Leaves		int			// number of nodes at the bottom level (holding the values)
+	Depths		[]int		// Depths[d] is the number of nodes d levels below the root
+	AvgDepth	float64		// average depth of the leaves (they are all at the bottom)
+	MaxDepth	int			// maximum depth of the leaves
+	KeyBytes	int			// total size of the values (8 bytes each)
+
+	NodeBytes		int		// estimated memory of the nodes
+	RefBytes		int		// estimated memory of the children slices
+	KeyHeapBytes	int		// always 0 (see above)
+}
+
+// HeapBytes returns the estimated memory taken by the set
+func (s *Stats) HeapBytes() int {
+	return s.NodeBytes + s.RefBytes + s.KeyHeapBytes
+}
+
+// Stats walks the set and collects its statistics
+func (t *Set) Stats() (s Stats) {
+	if t == nil || t.root == nil {
+		return
+	}
+	level := []*Node{t.root}
+
+	for depth := 0; len(level) > 0; depth++ {
+		s.Depths = append(s.Depths, len(level))
+		s.Nodes += len(level)
+
+		var next []*Node
+		for _, node := range level {
+			s.RefBytes += cap(node.children) * int(unsafe.Sizeof(node))
+			next = append(next, node.children...)
+		}
+		if depth == 7 {
+			s.Leaves   = len(level)
+			s.AvgDepth = float64(depth)
+			s.MaxDepth = depth
+		}
+		level = next
+	}
+	s.KeyBytes  = int(t.size) * 8
+	s.NodeBytes = s.Nodes * int(unsafe.Sizeof(Node{}))
+	return
+}